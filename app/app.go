@@ -1,8 +1,12 @@
 package app
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/jmoiron/sqlx"
@@ -21,17 +25,52 @@ const (
 	NewConnView
 	OpenConnView
 	Editor
-
-	NormalInfo    = "e - Editor | d - DataTable | D - Databases | s - Schemas | t - Tables | i - Indexes | A - Add | C - Connect | Q - Quit"
-	EditorInfo    = "i - Insert Mode | v - Visual Mode | V - Visual Mode (Whole Line) | Esc - Normal Mode/Exit Editor Mode"
-	DataTableInfo = "Arrow Keys - Select Row/Col | Enter - Expand Cell | Esc - Normal Mode/Exit Expanded Cell"
+	DiffConnView
+	DiffView
+	ExportView
+	ImportView
+	DumpView
+	HistoryView
+	SnippetView
+	SaveSnippetView
+	BindView
+	CellEditView
+	InsertRowView
+	ConfirmView
+	FilterView
+
+	NormalInfo    = "e - Editor | d - DataTable | D - Databases | s - Schemas | t - Tables | i - Indexes | p - Query Plan | A - Add | C - Connect | f - Schema Diff | E - Export Results | I - Import CSV/TSV | W - Dump to SQL | H - Query History | N - Snippets | b - Begin | c - Commit | r - Rollback | a - Toggle Autocommit | Ctrl-C - Cancel Query/Stop Fetching | Q - Quit"
+	EditorInfo    = "i - Insert Mode | v - Visual Mode | V - Visual Mode (Whole Line) | P - Explain Statement/Selection | S - Save Selection as Snippet | Esc - Normal Mode/Exit Editor Mode"
+	DataTableInfo = "Arrow Keys - Select Row/Col | Enter - Expand Cell | e - Edit Cell | Space - Mark Row | x - Delete Rows | s - Stop Fetching | > - Next Result Set | E - Export | Esc - Normal Mode/Exit Expanded Cell"
 	ListInfo      = "Up/Down - Select Item | Esc - Normal Mode"
 	TreeInfo      = "Up/Down - Select Item | Enter - Expand/Collapse Selection | Esc - NormalMode"
+	TableTreeInfo = "Up/Down - Select Item | Enter - Expand/Collapse Selection | S - Show SQL Definition | n - Insert Row | o - Browse Rows | Esc - NormalMode"
+	IndexTreeInfo = "Up/Down - Select Item | Enter - Expand/Collapse Selection | S - Show CREATE INDEX | Esc - NormalMode"
+	TextViewInfo  = "Up/Down/PgUp/PgDn - Scroll | Esc - Close"
+	DefInfo       = "Up/Down/PgUp/PgDn - Scroll | e - Open in Editor | Esc - Close"
 	OpenConnInfo  = "Up/Down - Select Connection | Enter - Connect | Esc - Cancel"
-	NewConnInfo   = "Tab - Change Selection | 1-4 - Change Driver Selection on Radio | Enter - Select Buttons (If highlighted) | Esc - Cancel"
+	DiffConnInfo  = "Up/Down - Select Connection | Enter - Select | Esc - Cancel"
+	DiffInfo      = "Up/Down - Select Item | Enter - Expand/Collapse Selection | S - Show Migration SQL | Esc - Close"
+	ImportInfo    = "Tab - Change Selection | 1-2 - Change Radio Selection | Enter - Import (If highlighted) | Esc - Cancel"
+	ImportTblInfo = "Up/Down - Select Table | Enter - Map Columns | Esc - Cancel"
+	ImportMapInfo = "Up/Down - Select Column | Left/Right - Change Field | Enter - Import | Esc - Cancel"
+	DumpInfo      = "Tab - Change Selection | Enter - Dump (If highlighted) | Esc - Cancel"
+	HistoryInfo   = "Type - Search | Up/Down - Select Statement | Enter - Paste Into Editor | Esc - Cancel"
+	SnippetInfo   = "Up/Down - Select Snippet | Enter - Insert Into Editor | Esc - Cancel"
+	SaveSnipInfo  = "Tab - Change Selection | 1-2 - Change Scope Selection on Radio | Enter - Save (If highlighted) | Esc - Cancel"
+	BindInfo      = "Tab/Shift-Tab - Next/Previous Parameter | Ctrl-N - Toggle NULL | Enter - Run | Esc - Back to Editor"
+	CellEditInfo  = "Type - Edit Value | Ctrl-N - Toggle NULL | Enter - Show UPDATE | Esc - Cancel"
+	CellSQLInfo   = "Up/Down/PgUp/PgDn - Scroll | Enter - Run | Backspace - Back to Value | Esc - Cancel"
+	InsertInfo    = "Tab/Shift-Tab - Next/Previous Column | Ctrl-N - Toggle NULL | Enter - Insert | Esc - Cancel"
+	ConfirmInfo   = "Up/Down/PgUp/PgDn - Scroll | Enter - Run | Esc - Cancel"
+	BrowseInfo    = "[/] - Previous/Next Page | o - Sort by Column | w - Filter | e - Edit Cell | Space - Mark Row | x - Delete Rows | Esc - Normal Mode"
+	FilterInfo    = "Type - WHERE Condition | Enter - Apply | Esc - Cancel"
 )
 
 var (
+	ExportInfo  = fmt.Sprintf("Tab - Change Selection | 1-%d - Change Format Selection on Radio | Enter - Export (If highlighted) | Esc - Cancel", len(db.ExportFormats()))
+	NewConnInfo = fmt.Sprintf("Tab - Change Selection | 1-%d - Change Driver Selection on Radio | Enter - Select Buttons (If highlighted) | Esc - Cancel", len(db.Drivers()))
+
 	defStyle   tcell.Style = tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite)
 	hlStyle    tcell.Style = tcell.StyleDefault.Background(tcell.ColorGreen).Foreground(tcell.ColorWhite)
 	maxX, maxY int
@@ -44,14 +83,80 @@ var (
 	pWidth, pHeight              int = 85, 30
 )
 
+// queryDoneEvent is posted by the query worker once it has finished, err is
+// nil if every statement succeeded.
+type queryDoneEvent struct {
+	tcell.EventTime
+	err error
+}
+
+// tableDataEvent carries query results from the worker back to the event
+// loop so the data table is only touched from one goroutine.
+type tableDataEvent struct {
+	tcell.EventTime
+	source    components.RowSource
+	resultMsg []rune
+}
+
+// callbackEvent runs fn on the event loop, used by components that do work
+// in the background and need to apply the result on the UI goroutine.
+type callbackEvent struct {
+	tcell.EventTime
+	fn func()
+}
+
+// planEvent carries a query plan from the worker to the plan tree.
+type planEvent struct {
+	tcell.EventTime
+	plan *db.PlanNode
+}
+
+// tablesEvent carries refreshed schema info from the worker after DDL.
+type tablesEvent struct {
+	tcell.EventTime
+	tables []db.Table
+}
+
 type Sqline struct {
 	state                        MainAppState
 	database                     db.Database
+	cancelQuery                  context.CancelFunc
+	releaseQuery                 context.CancelFunc
+	txStatus                     db.TxStatus
+	quitPending                  bool
+	switchPending                bool
 	screen                       tcell.Screen
 	config                       *util.SqlineConf
 	mainView                     *views.MainView
 	newConnView                  *views.NewConnView
 	openConnView                 *views.OpenConnView
+	diffConnView                 *views.OpenConnView
+	diffView                     *views.DiffView
+	diffFrom                     *util.DBEntry
+	exportView                   *views.ExportView
+	importView                   *views.ImportView
+	dumpView                     *views.DumpView
+	historyView                  *views.HistoryView
+	history                      []util.HistoryEntry
+	historyPath                  string
+	snippetView                  *views.SnippetView
+	saveSnippetView              *views.SaveSnippetView
+	pendingSnippet               string
+	connName                     string
+	bindView                     *views.BindView
+	bindExec                     db.ExecSQLFunc
+	bindScript                   []rune
+	lastBinds                    map[string]db.Binds
+	cellEditView                 *views.CellEditView
+	cellEdit                     *cellEdit
+	insertRowView                *views.InsertRowView
+	insertTable                  db.Table
+	confirmView                  *views.ConfirmView
+	rowDelete                    *rowDelete
+	filterView                   *views.FilterView
+	browse                       *db.Browse
+	browseRows                   *db.ResultSet
+	tables                       []db.Table
 	maxX, maxY                   int
 	pLeft, pTop, pRight, pBottom int
 	pWidth, pHeight              int
@@ -74,6 +179,8 @@ func createSqline(maxX, maxY int, screen tcell.Screen) *Sqline {
 		pWidth:  85,
 		pHeight: 30,
 		screen:  screen,
+
+		lastBinds: make(map[string]db.Binds),
 	}
 
 	sqline.CalcPopupSize()
@@ -84,9 +191,28 @@ func createSqline(maxX, maxY int, screen tcell.Screen) *Sqline {
 	}
 
 	sqline.config = conf
+	err = sqline.loadHistory()
+	if err != nil {
+		defer sqline.handleError(err)
+	}
+
 	sqline.mainView = views.CreateMainView(0, 0, maxX, maxY, sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, true, true, buf, &defStyle, &hlStyle)
 	sqline.newConnView = views.CreateNewConnView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, &hlStyle, sqline.createTestFunc(), sqline.createSaveFunc())
+	sqline.mainView.SetPostFunc(sqline.postFunc())
 	sqline.openConnView = views.CreateOpenConnView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, &hlStyle, sqline.config.SavedConns, sqline.createSelectFunc())
+	sqline.diffConnView = views.CreateOpenConnView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, &hlStyle, sqline.config.SavedConns, sqline.createDiffSelectFunc())
+	sqline.exportView = views.CreateExportView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, &hlStyle, sqline.createExportFunc())
+	sqline.importView = views.CreateImportView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, &hlStyle, createImportLoadFunc(), sqline.createImportFunc())
+	sqline.historyView = views.CreateHistoryView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, sqline.createPasteFunc())
+	sqline.snippetView = views.CreateSnippetView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, sqline.createPasteFunc())
+	sqline.saveSnippetView = views.CreateSaveSnippetView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, &hlStyle, sqline.createSaveSnippetFunc())
+	sqline.bindView = views.CreateBindView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, sqline.createBindFunc())
+	sqline.cellEditView = views.CreateCellEditView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, sqline.createPrepareEditFunc(), sqline.createConfirmEditFunc())
+	sqline.insertRowView = views.CreateInsertRowView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, sqline.createInsertFunc())
+	sqline.confirmView = views.CreateConfirmView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, sqline.createConfirmDeleteFunc())
+	sqline.filterView = views.CreateFilterView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pTop+11, &defStyle, sqline.createFilterFunc())
+	sqline.dumpView = views.CreateDumpView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle, sqline.createDumpFunc())
+	sqline.diffView = views.CreateDiffView(sqline.pLeft, sqline.pTop, sqline.pRight, sqline.pBottom, &defStyle)
 
 	sqline.setInfo()
 	return &sqline
@@ -111,7 +237,9 @@ func (sqline *Sqline) createTestFunc() views.TestFunc {
 
 func (sqline *Sqline) createSelectFunc() views.SelectFunc {
 	return func(dbEntry util.DBEntry) {
-		sqline.setDB(dbEntry)
+		if !sqline.setDB(dbEntry) {
+			return
+		}
 		screen.Fill(' ', defStyle)
 		sqline.state = NormalMode
 		sqline.mainView.SetStatus("Normal")
@@ -137,6 +265,7 @@ func (sqline *Sqline) createSaveFunc() views.SaveFunc {
 		} else {
 			sqline.config.SavedConns = conf.SavedConns
 			sqline.openConnView.SetConns(conf.SavedConns)
+			sqline.diffConnView.SetConns(conf.SavedConns)
 		}
 
 		sqline.state = NormalMode
@@ -157,9 +286,15 @@ func (sqline *Sqline) setInfo() {
 	case sqline.state == Editor && sqline.mainView.State == views.Editor:
 		sqline.mainView.SetInfo([]rune(EditorInfo))
 	case sqline.state == MainView && sqline.mainView.State == views.TblList:
-		fallthrough
+		sqline.mainView.SetInfo([]rune(TableTreeInfo))
+	case sqline.state == MainView && sqline.mainView.State == views.Definition:
+		sqline.mainView.SetInfo([]rune(DefInfo))
 	case sqline.state == MainView && sqline.mainView.State == views.Indexes:
+		sqline.mainView.SetInfo([]rune(IndexTreeInfo))
+	case sqline.state == MainView && sqline.mainView.State == views.Plan:
 		sqline.mainView.SetInfo([]rune(TreeInfo))
+	case sqline.state == MainView && sqline.mainView.State == views.DataTable && sqline.browsing():
+		sqline.mainView.SetInfo([]rune(sqline.browseStatus() + " | " + BrowseInfo))
 	case sqline.state == MainView && sqline.mainView.State == views.DataTable:
 		sqline.mainView.SetInfo([]rune(DataTableInfo))
 	case sqline.state == MainView && sqline.mainView.State == views.SchemaList:
@@ -170,33 +305,84 @@ func (sqline *Sqline) setInfo() {
 		sqline.mainView.SetInfo([]rune(OpenConnInfo))
 	case sqline.state == NewConnView:
 		sqline.mainView.SetInfo([]rune(NewConnInfo))
+	case sqline.state == ExportView:
+		sqline.mainView.SetInfo([]rune(ExportInfo))
+	case sqline.state == ImportView && sqline.importView.Stage() == views.ImportTables:
+		sqline.mainView.SetInfo([]rune(ImportTblInfo))
+	case sqline.state == ImportView && sqline.importView.Stage() == views.ImportMapping:
+		sqline.mainView.SetInfo([]rune(ImportMapInfo))
+	case sqline.state == ImportView:
+		sqline.mainView.SetInfo([]rune(ImportInfo))
+	case sqline.state == DumpView:
+		sqline.mainView.SetInfo([]rune(DumpInfo))
+	case sqline.state == HistoryView:
+		sqline.mainView.SetInfo([]rune(HistoryInfo))
+	case sqline.state == SnippetView:
+		sqline.mainView.SetInfo([]rune(SnippetInfo))
+	case sqline.state == SaveSnippetView:
+		sqline.mainView.SetInfo([]rune(SaveSnipInfo))
+	case sqline.state == BindView:
+		sqline.mainView.SetInfo([]rune(BindInfo))
+	case sqline.state == CellEditView && sqline.cellEditView.Confirming():
+		sqline.mainView.SetInfo([]rune(CellSQLInfo))
+	case sqline.state == CellEditView:
+		sqline.mainView.SetInfo([]rune(CellEditInfo))
+	case sqline.state == InsertRowView:
+		sqline.mainView.SetInfo([]rune(InsertInfo))
+	case sqline.state == ConfirmView:
+		sqline.mainView.SetInfo([]rune(ConfirmInfo))
+	case sqline.state == FilterView:
+		sqline.mainView.SetInfo([]rune(FilterInfo))
+	case sqline.state == DiffConnView:
+		sqline.mainView.SetInfo([]rune(DiffConnInfo))
+	case sqline.state == DiffView && sqline.diffView.ShowingSQL():
+		sqline.mainView.SetInfo([]rune(TextViewInfo))
+	case sqline.state == DiffView:
+		sqline.mainView.SetInfo([]rune(DiffInfo))
 	}
 
 }
 
-func (sqline *Sqline) setDB(dbEntry util.DBEntry) {
-	var err error
-	var database db.Database
-	switch dbEntry.Driver {
-	case "sqlite3":
-		database, err = db.CreateSqlite(dbEntry.ConnStr, sqline.mainView.TableFunc(), sqline.updateDBInfoFunc())
-	case "postgres":
-		database = db.CreatePg()
+// setDB connects to dbEntry in place of the current database, it reports
+// false if the switch was held back. With a transaction open the first
+// attempt only warns, picking the connection again rolls it back. Any
+// running query is cancelled and the old database is closed.
+func (sqline *Sqline) setDB(dbEntry util.DBEntry) bool {
+	if sqline.database != nil && sqline.txStatus.Open && !sqline.switchPending {
+		sqline.switchPending = true
+		msg := fmt.Sprintf("A transaction with %d statements is open, select the connection again to roll it back and switch", sqline.txStatus.Statements)
+		sqline.mainView.SetInfo([]rune(msg))
+		return false
 	}
 
+	database, err := db.Open(dbEntry.Driver, dbEntry.ConnStr, sqline.postTableDataFunc(), sqline.postTablesFunc())
 	if err != nil {
 		sqline.handleError(err)
-		return
+		return true
+	}
+
+	if sqline.database != nil {
+		sqline.closeDB()
 	}
 
 	sqline.database = database
+	sqline.tables = nil
+	sqline.connName = dbEntry.Name
+	driver, _ := sqline.database.Info()
+	sqline.mainView.SetDriver(driver)
+	sqline.database.SetContinueOnError(sqline.config.ContinueOnError)
+	sqline.database.SetHistoryFunc(sqline.createHistoryFunc(dbEntry.Name))
+	sqline.mainView.SetSQLFunc(sqline.createRunQueryFunc(sqline.database.GetExecSQLFunc()))
+	sqline.mainView.SetExplainFunc(sqline.createExplainFunc())
+	sqline.mainView.SetSnippetFunc(sqline.createSnippetFunc())
+	sqline.updateTransaction()
 	showDB, showSchema := true, true
 	databases, err := sqline.database.GetDatabases()
 	if err != nil && errors.Is(db.ErrNotSupported, err) {
 		showDB = false
 	} else if err != nil {
 		sqline.handleError(err)
-		return
+		return true
 	} else {
 		sqline.setDBInfo(databases)
 	}
@@ -206,7 +392,7 @@ func (sqline *Sqline) setDB(dbEntry util.DBEntry) {
 		showSchema = false
 	} else if err != nil {
 		sqline.handleError(err)
-		return
+		return true
 	} else {
 		sqline.mainView.SetSchemaList(schemas)
 	}
@@ -214,22 +400,245 @@ func (sqline *Sqline) setDB(dbEntry util.DBEntry) {
 	tables, err := sqline.database.GetTables()
 	if err != nil {
 		sqline.handleError(err)
-		return
+		return true
 	}
 
-	sqline.mainView.SetSQLFunc(sqline.database.GetExecSQLFunc())
-	sqline.mainView.SetTableTree(tables)
-	sqline.mainView.SetIndexTree(tables)
+	sqline.updateDBInfoFunc()(tables)
 	sqline.mainView.SetVisibleComponents(showDB, showSchema, sqline.screen)
+	return true
 }
 
-func (sqline *Sqline) updateDBInfoFunc() func([]db.Table) {
+// updateDBInfoFunc shows the tables in the trees and keeps them for the
+// views that need the schema without querying it on the event loop.
+func (sqline *Sqline) updateDBInfoFunc() db.UpdateViewFunc {
 	return func(tables []db.Table) {
+		sqline.tables = tables
 		sqline.mainView.SetTableTree(tables)
 		sqline.mainView.SetIndexTree(tables)
 	}
 }
 
+func (sqline *Sqline) postTableDataFunc() db.ResultFunc {
+	return func(rows *db.ResultSet, resultMsg []rune) {
+		ev := &tableDataEvent{resultMsg: resultMsg}
+		if rows != nil {
+			ev.source = rows
+		}
+		ev.SetEventNow()
+		sqline.screen.PostEventWait(ev)
+	}
+}
+
+func (sqline *Sqline) postFunc() components.PostFunc {
+	return func(fn func()) {
+		ev := &callbackEvent{fn: fn}
+		ev.SetEventNow()
+		sqline.screen.PostEventWait(ev)
+	}
+}
+
+func (sqline *Sqline) postTablesFunc() db.UpdateViewFunc {
+	return func(tables []db.Table) {
+		ev := &tablesEvent{tables: tables}
+		ev.SetEventNow()
+		sqline.screen.PostEventWait(ev)
+	}
+}
+
+// createRunQueryFunc wraps execFunc so queries from the editor run on a
+// worker goroutine, results come back through screen.PostEventWait and the query
+// can be cancelled with Ctrl-C while it's running. Scripts with bind
+// parameters ask for their values first.
+func (sqline *Sqline) createRunQueryFunc(execFunc db.ExecSQLFunc) components.ExecSQLFunc {
+	return func(cmd []rune) error {
+		params := db.ScriptParams(sqline.database, string(cmd))
+		if len(params) > 0 {
+			sqline.startBind(execFunc, cmd, params)
+			return nil
+		}
+
+		sqline.runQuery(func(ctx context.Context) error {
+			return execFunc(ctx, cmd, nil)
+		})
+
+		return nil
+	}
+}
+
+// createExplainFunc shows the plan for a statement from the editor, it's run
+// on the worker the same way as a query.
+func (sqline *Sqline) createExplainFunc() components.ExecSQLFunc {
+	return func(cmd []rune) error {
+		explainer, ok := sqline.database.(db.Explainer)
+		if !ok {
+			sqline.mainView.SetInfo([]rune("Query plans aren't supported for this database"))
+			return nil
+		}
+
+		sqline.runQuery(func(ctx context.Context) error {
+			plan, err := explainer.Explain(ctx, string(cmd))
+			if err != nil {
+				return err
+			}
+
+			ev := &planEvent{plan: plan}
+			ev.SetEventNow()
+			sqline.screen.PostEventWait(ev)
+			return nil
+		})
+
+		return nil
+	}
+}
+
+// runQuery runs work on a worker goroutine and posts a queryDoneEvent once
+// it's finished. The query's context is kept alive after it finishes since
+// the data table is still reading rows from it, it's released when the next
+// query starts.
+func (sqline *Sqline) runQuery(work func(ctx context.Context) error) {
+	if sqline.cancelQuery != nil {
+		sqline.mainView.SetInfo([]rune("A query is already running"))
+		return
+	}
+
+	sqline.mainView.StopFetching()
+	if sqline.releaseQuery != nil {
+		sqline.releaseQuery()
+		sqline.releaseQuery = nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sqline.cancelQuery = cancel
+
+	start := time.Now()
+	sqline.mainView.SetRunning(start)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// Dropping a tick is harmless, the next one redraws the timer.
+				sqline.screen.PostEvent(tcell.NewEventInterrupt(nil))
+			}
+		}
+	}()
+
+	go func() {
+		defer close(done)
+
+		err := work(ctx)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		ev := &queryDoneEvent{err: err}
+		ev.SetEventNow()
+		sqline.screen.PostEventWait(ev)
+	}()
+}
+
+func (sqline *Sqline) queryDone(err error) {
+	sqline.releaseQuery = sqline.cancelQuery
+	sqline.cancelQuery = nil
+	sqline.mainView.StopRunning()
+	sqline.updateTransaction()
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		sqline.mainView.SetInfo([]rune("Query cancelled"))
+	case err != nil:
+		sqline.handleError(err)
+	}
+}
+
+// txAction runs one of the transaction actions from normal mode, they're
+// refused while a query is running since it's using the transaction's
+// connection.
+func (sqline *Sqline) txAction(action func() error, msg string) {
+	switch {
+	case sqline.database == nil:
+		sqline.mainView.SetInfo([]rune("Not connected to a database"))
+		return
+	case sqline.cancelQuery != nil:
+		sqline.mainView.SetInfo([]rune("A query is already running"))
+		return
+	}
+
+	sqline.mainView.StopFetching()
+	err := action()
+	if err != nil {
+		sqline.handleError(err)
+	} else {
+		sqline.mainView.SetInfo([]rune(msg))
+	}
+
+	sqline.updateTransaction()
+}
+
+func (sqline *Sqline) toggleAutocommit() error {
+	sqline.database.SetAutocommit(!sqline.txStatus.Autocommit)
+	return nil
+}
+
+func (sqline *Sqline) updateTransaction() {
+	if sqline.database == nil {
+		return
+	}
+
+	sqline.txStatus = sqline.database.Transaction()
+	sqline.mainView.SetTransaction(sqline.txStatus)
+}
+
+// closeDB cancels the running query, stops reading the data table's rows
+// and rolls back any open transaction before closing the database.
+func (sqline *Sqline) closeDB() {
+	if sqline.cancelQuery != nil {
+		sqline.cancelQuery()
+	}
+	sqline.mainView.StopFetching()
+	if sqline.releaseQuery != nil {
+		sqline.releaseQuery()
+		sqline.releaseQuery = nil
+	}
+
+	if sqline.database.Transaction().Open {
+		sqline.database.Rollback()
+	}
+
+	err := sqline.database.Close()
+	if err != nil {
+		sqline.handleError(err)
+	}
+}
+
+// confirmQuit warns the first time Q is pressed with a transaction open,
+// pressing it again rolls the transaction back and quits.
+func (sqline *Sqline) confirmQuit() bool {
+	if !sqline.txStatus.Open {
+		return true
+	}
+
+	if !sqline.quitPending {
+		sqline.quitPending = true
+		msg := fmt.Sprintf("A transaction with %d statements is open, press Q again to roll it back and quit", sqline.txStatus.Statements)
+		sqline.mainView.SetInfo([]rune(msg))
+		return false
+	}
+
+	if sqline.cancelQuery != nil {
+		sqline.cancelQuery()
+	}
+	sqline.database.Rollback()
+
+	return true
+}
+
 func (sqline *Sqline) setDBInfo(dbInfo []db.DbInfo) {
 	sqline.mainView.SetDatabaseList(dbInfo)
 }
@@ -272,14 +681,61 @@ func Run() {
 		case *tcell.EventResize:
 			screen.Sync()
 			maxX, maxY = screen.Size()
+		case *tableDataEvent:
+			sqline.mainView.TableFunc()(ev.source, ev.resultMsg)
+		case *callbackEvent:
+			ev.fn()
+		case *planEvent:
+			sqline.mainView.SetPlan(ev.plan)
+			if sqline.state == NormalMode || sqline.state == MainView {
+				sqline.state = MainView
+				sqline.mainView.SetState(views.Plan)
+				sqline.setInfo()
+			}
+		case *diffEvent:
+			sqline.showDiff(ev)
+		case *tablesEvent:
+			sqline.updateDBInfoFunc()(ev.tables)
+		case *queryDoneEvent:
+			sqline.queryDone(ev.err)
 		case *tcell.EventKey:
+			if ev.Rune() != 'Q' {
+				sqline.quitPending = false
+			}
+
 			switch {
-			case ev.Key() == tcell.KeyCtrlC || ev.Rune() == 'Q':
-				screen.Fini()
-				return
-			case ev.Key() == tcell.KeyEsc && sqline.mainView.State == views.DataTableExpanded:
+			case ev.Key() == tcell.KeyCtrlC:
+				if sqline.cancelQuery != nil {
+					sqline.cancelQuery()
+				} else {
+					sqline.mainView.StopFetching()
+				}
+			case ev.Rune() == 'Q' && sqline.state == NormalMode:
+				if sqline.confirmQuit() {
+					screen.Fini()
+					return
+				}
+			case ev.Key() == tcell.KeyEsc && (sqline.mainView.State == views.DataTableExpanded || sqline.mainView.State == views.Definition):
 				sqline.mainView.HandleInput(ev)
+				sqline.setInfo()
 				screen.Fill(' ', defStyle)
+			case ev.Key() == tcell.KeyEsc && sqline.state == DiffView && sqline.diffView.ShowingSQL():
+				sqline.diffView.HandleInput(ev)
+				sqline.setInfo()
+				screen.Fill(' ', defStyle)
+			case ev.Key() == tcell.KeyEsc && sqline.state == BindView:
+				sqline.closeBind()
+			case ev.Key() == tcell.KeyEsc && sqline.state == CellEditView:
+				sqline.state = MainView
+				sqline.mainView.SetState(views.DataTable)
+				sqline.setInfo()
+				screen.Fill(' ', defStyle)
+			case ev.Key() == tcell.KeyEsc && sqline.state == InsertRowView:
+				sqline.closeInsertRow()
+			case ev.Key() == tcell.KeyEsc && sqline.state == ConfirmView:
+				sqline.closeConfirm()
+			case ev.Key() == tcell.KeyEsc && sqline.state == FilterView:
+				sqline.closeFilter()
 			case ev.Key() == tcell.KeyEsc && sqline.mainView.EditorInNormalMode():
 				sqline.state = NormalMode
 				sqline.setInfo()
@@ -288,6 +744,9 @@ func Run() {
 				sqline.mainView.SetStatus("Normal")
 			case ev.Key() == tcell.KeyEsc && !sqline.mainView.EditorInNormalMode():
 				sqline.mainView.HandleInput(ev)
+			case ev.Rune() == 'e' && sqline.state == MainView && sqline.mainView.State == views.Definition:
+				sql := strings.TrimRight(sqline.mainView.CloseDefinition(), "; \t\n")
+				sqline.createPasteFunc()(sql + ";\n")
 			case ev.Rune() == 'e' && sqline.state == NormalMode:
 				sqline.state = Editor
 				sqline.mainView.SetState(views.Editor)
@@ -308,6 +767,14 @@ func Run() {
 				sqline.state = MainView
 				sqline.mainView.SetState(views.DbList)
 				sqline.setInfo()
+			case ev.Rune() == 'p' && sqline.state == NormalMode:
+				if !sqline.mainView.HasPlan() {
+					sqline.mainView.SetInfo([]rune("No query plan to show, press P in the editor to explain a statement"))
+					break
+				}
+				sqline.state = MainView
+				sqline.mainView.SetState(views.Plan)
+				sqline.setInfo()
 			case ev.Rune() == 'i' && sqline.state == NormalMode:
 				sqline.state = MainView
 				sqline.mainView.SetState(views.Indexes)
@@ -317,22 +784,110 @@ func Run() {
 				sqline.mainView.SetStatus("NewConn")
 				sqline.setInfo()
 			case ev.Rune() == 'C' && sqline.state == NormalMode:
+				sqline.switchPending = false
 				sqline.state = OpenConnView
 				sqline.mainView.SetStatus("OpenConn")
 				sqline.setInfo()
+			case ev.Rune() == 'E' && (sqline.state == NormalMode || (sqline.state == MainView && sqline.mainView.State == views.DataTable)):
+				sqline.startExport()
+			case ev.Rune() == 'e' && sqline.state == MainView && sqline.mainView.State == views.DataTable:
+				sqline.startCellEdit()
+			case ev.Rune() == 'x' && sqline.state == MainView && sqline.mainView.State == views.DataTable:
+				sqline.startDeleteRows()
+			case ev.Rune() == '>' && sqline.state == MainView && sqline.mainView.State == views.DataTable:
+				sqline.nextResultSet()
+			case ev.Rune() == 'n' && sqline.state == MainView && sqline.mainView.State == views.TblList:
+				sqline.startInsertRow()
+			case ev.Rune() == 'o' && sqline.state == MainView && sqline.mainView.State == views.TblList:
+				sqline.startBrowse()
+			case (ev.Rune() == '[' || ev.Rune() == ']') && sqline.state == MainView && sqline.mainView.State == views.DataTable && sqline.browsing():
+				if ev.Rune() == '[' {
+					sqline.browsePage(-1)
+				} else {
+					sqline.browsePage(1)
+				}
+			case ev.Rune() == 'o' && sqline.state == MainView && sqline.mainView.State == views.DataTable && sqline.browsing():
+				sqline.browseSort()
+			case ev.Rune() == 'w' && sqline.state == MainView && sqline.mainView.State == views.DataTable && sqline.browsing():
+				sqline.startFilter()
+			case ev.Rune() == 'I' && sqline.state == NormalMode:
+				sqline.startImport()
+			case ev.Rune() == 'H' && sqline.state == NormalMode:
+				sqline.startHistory()
+			case ev.Rune() == 'N' && sqline.state == NormalMode:
+				sqline.startSnippets()
+			case ev.Rune() == 'W' && sqline.state == NormalMode:
+				sqline.startDump()
+			case ev.Rune() == 'f' && sqline.state == NormalMode:
+				sqline.startDiff()
+			case ev.Rune() == 'b' && sqline.state == NormalMode:
+				sqline.txAction(func() error { return sqline.database.Begin() }, "Transaction started")
+			case ev.Rune() == 'c' && sqline.state == NormalMode:
+				sqline.txAction(func() error { return sqline.database.Commit() }, "Transaction committed")
+			case ev.Rune() == 'r' && sqline.state == NormalMode:
+				sqline.txAction(func() error { return sqline.database.Rollback() }, "Transaction rolled back")
+			case ev.Rune() == 'a' && sqline.state == NormalMode:
+				msg := "Autocommit off, statements will open a transaction"
+				if !sqline.txStatus.Autocommit {
+					msg = "Autocommit on"
+				}
+				sqline.txAction(sqline.toggleAutocommit, msg)
 			default:
 				switch sqline.state {
 				case NewConnView:
 					sqline.newConnView.HandleInput(ev)
 				case OpenConnView:
 					sqline.openConnView.HandleInput(ev)
+				case ExportView:
+					sqline.exportView.HandleInput(ev)
+				case DumpView:
+					sqline.dumpView.HandleInput(ev)
+				case HistoryView:
+					sqline.historyView.HandleInput(ev)
+				case SnippetView:
+					sqline.snippetView.HandleInput(ev)
+				case SaveSnippetView:
+					sqline.saveSnippetView.HandleInput(ev)
+				case BindView:
+					sqline.bindView.HandleInput(ev)
+				case CellEditView:
+					confirming := sqline.cellEditView.Confirming()
+					sqline.cellEditView.HandleInput(ev)
+					if sqline.state == CellEditView && sqline.cellEditView.Confirming() != confirming {
+						screen.Fill(' ', defStyle)
+						sqline.setInfo()
+					}
+				case InsertRowView:
+					sqline.insertRowView.HandleInput(ev)
+				case ConfirmView:
+					sqline.confirmView.HandleInput(ev)
+				case FilterView:
+					sqline.filterView.HandleInput(ev)
+				case ImportView:
+					prevStage := sqline.importView.Stage()
+					sqline.importView.HandleInput(ev)
+					if sqline.state == ImportView && sqline.importView.Stage() != prevStage {
+						screen.Fill(' ', defStyle)
+						sqline.setInfo()
+					}
+				case DiffConnView:
+					sqline.diffConnView.HandleInput(ev)
+				case DiffView:
+					sqline.diffView.HandleInput(ev)
+					if ev.Rune() == 'S' {
+						sqline.setInfo()
+					}
 				case Editor:
 					if ev.Rune() == '\t' {
 						sync = true
 					}
 					fallthrough
 				case MainView:
+					prevViewState := sqline.mainView.State
 					sqline.mainView.HandleInput(ev)
+					if sqline.mainView.State != prevViewState {
+						sqline.setInfo()
+					}
 				}
 			}
 
@@ -347,6 +902,32 @@ func Run() {
 			sqline.newConnView.Render(screen)
 		case OpenConnView:
 			sqline.openConnView.Render(screen)
+		case ExportView:
+			sqline.exportView.Render(screen)
+		case ImportView:
+			sqline.importView.Render(screen)
+		case DumpView:
+			sqline.dumpView.Render(screen)
+		case HistoryView:
+			sqline.historyView.Render(screen)
+		case SnippetView:
+			sqline.snippetView.Render(screen)
+		case SaveSnippetView:
+			sqline.saveSnippetView.Render(screen)
+		case BindView:
+			sqline.bindView.Render(screen)
+		case CellEditView:
+			sqline.cellEditView.Render(screen)
+		case InsertRowView:
+			sqline.insertRowView.Render(screen)
+		case ConfirmView:
+			sqline.confirmView.Render(screen)
+		case FilterView:
+			sqline.filterView.Render(screen)
+		case DiffConnView:
+			sqline.diffConnView.Render(screen)
+		case DiffView:
+			sqline.diffView.Render(screen)
 		}
 
 		if sync {
@@ -359,6 +940,16 @@ func Run() {
 
 func (sqline *Sqline) ResetViews() {
 	sqline.newConnView.Reset()
+	sqline.exportView.Reset()
+	sqline.importView.Reset()
+	sqline.dumpView.Reset()
+	sqline.historyView.Reset()
+	sqline.saveSnippetView.Reset()
+	sqline.bindView.Reset()
+	sqline.cellEditView.Reset()
+	sqline.insertRowView.Reset()
+	sqline.confirmView.Reset()
+	sqline.filterView.Reset()
 }
 
 func (sqline *Sqline) CalcPopupSize() {
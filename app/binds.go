@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/views"
+)
+
+// bindKey identifies a script for remembering its bind values, whitespace
+// differences don't count.
+func bindKey(cmd []rune) string {
+	return strings.Join(strings.Fields(string(cmd)), " ")
+}
+
+// startBind opens the form for the script's bind parameters, filled in with
+// the values it was last run with.
+func (sqline *Sqline) startBind(execFunc db.ExecSQLFunc, cmd []rune, params []string) {
+	sqline.bindExec = execFunc
+	sqline.bindScript = cmd
+	sqline.bindView.SetParams(params, sqline.lastBinds[bindKey(cmd)])
+	sqline.state = BindView
+	sqline.mainView.SetStatus("Bind")
+	sqline.setInfo()
+}
+
+// closeBind goes back to the editor with the selection left as it was.
+func (sqline *Sqline) closeBind() {
+	sqline.state = Editor
+	sqline.mainView.SetState(sqline.mainView.State)
+	sqline.setInfo()
+	screen.Fill(' ', defStyle)
+}
+
+// createBindFunc runs the pending script with the values from the form and
+// remembers them for the next time it's run.
+func (sqline *Sqline) createBindFunc() views.BindFunc {
+	return func(binds db.Binds) error {
+		if sqline.cancelQuery != nil {
+			return ErrQueryRunning
+		}
+
+		execFunc, cmd := sqline.bindExec, sqline.bindScript
+		sqline.lastBinds[bindKey(cmd)] = binds
+		sqline.closeBind()
+
+		sqline.runQuery(func(ctx context.Context) error {
+			return execFunc(ctx, cmd, binds)
+		})
+
+		return nil
+	}
+}
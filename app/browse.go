@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/views"
+)
+
+const browsePageSize = 500
+
+// startBrowse opens the first page of the table selected in the table tree
+// in the data table.
+func (sqline *Sqline) startBrowse() {
+	if sqline.database == nil {
+		sqline.mainView.SetInfo([]rune("Not connected to a database"))
+		return
+	}
+
+	table, ok := sqline.mainView.SelectedTable()
+	if !ok {
+		sqline.mainView.SetInfo([]rune("Select a table to browse"))
+		return
+	}
+
+	sqline.loadBrowse(db.Browse{Table: table, PageSize: browsePageSize})
+}
+
+// browsing reports whether the data table is showing a page from the table
+// browser.
+func (sqline *Sqline) browsing() bool {
+	rs, ok := sqline.mainView.TableSource().(*db.ResultSet)
+	return ok && rs != nil && rs == sqline.browseRows
+}
+
+// loadBrowse runs the query for a page on the worker, the browser only
+// moves to it once it's loaded. Starting the query stops the page being
+// shown so when it fails, a bad filter say, that page is loaded again.
+// Paging past a last page that was exactly full stays where it is.
+func (sqline *Sqline) loadBrowse(next db.Browse) {
+	database := sqline.database
+
+	var prev *db.Browse
+	if sqline.browsing() {
+		page := *sqline.browse
+		prev = &page
+	}
+
+	sqline.runQuery(func(ctx context.Context) error {
+		rows, err := next.Select(ctx, database)
+		if err != nil {
+			if prev != nil && ctx.Err() == nil {
+				if rows, prevErr := prev.Select(ctx, database); prevErr == nil {
+					sqline.postFunc()(func() {
+						sqline.showBrowse(*prev, rows)
+					})
+				}
+			}
+			return err
+		}
+
+		if count, done := rows.Count(); next.Page > 0 && done && count == 0 {
+			rows.Close()
+			sqline.postFunc()(func() {
+				sqline.mainView.SetInfo([]rune("Already on the last page"))
+			})
+			return nil
+		}
+
+		sqline.postFunc()(func() {
+			sqline.showBrowse(next, rows)
+		})
+		return nil
+	})
+}
+
+// showBrowse puts a page that has been loaded in the data table.
+func (sqline *Sqline) showBrowse(page db.Browse, rows *db.ResultSet) {
+	sqline.browse = &page
+	sqline.browseRows = rows
+	sqline.mainView.TableFunc()(rows, nil)
+	if sqline.state == NormalMode || sqline.state == MainView {
+		sqline.state = MainView
+		sqline.mainView.SetState(views.DataTable)
+		sqline.setInfo()
+	}
+}
+
+// browseStatus describes the page being shown.
+func (sqline *Sqline) browseStatus() string {
+	browse := sqline.browse
+	status := fmt.Sprintf("%s page %d", browse.Table.Name, browse.Page+1)
+	if browse.Sort != "" {
+		status += " by " + browse.Sort
+		if browse.Desc {
+			status += " DESC"
+		}
+	}
+	if browse.Filter != "" {
+		status += " where " + browse.Filter
+	}
+
+	return status
+}
+
+// browsePage moves forward or back a page, there's no next page once a
+// page has been read to the end short of a full page. A page that stopped
+// fetching early doesn't say whether there's another one so it's tried.
+func (sqline *Sqline) browsePage(delta int) {
+	next := *sqline.browse
+	next.Page += delta
+
+	count, done := sqline.browseRows.Count()
+	switch {
+	case next.Page < 0:
+		sqline.mainView.SetInfo([]rune("Already on the first page"))
+		return
+	case delta > 0 && done && count < next.PageSize:
+		sqline.mainView.SetInfo([]rune("Already on the last page"))
+		return
+	}
+
+	sqline.loadBrowse(next)
+}
+
+// browseSort sorts by the selected column of the data table, sorting by it
+// again reverses the order.
+func (sqline *Sqline) browseSort() {
+	_, col, ok := sqline.mainView.SelectedCell()
+	if !ok {
+		sqline.mainView.SetInfo([]rune("Select a cell in the column to sort by"))
+		return
+	}
+
+	next := *sqline.browse
+	next.SortBy(sqline.browseRows.Columns()[col].Name)
+	sqline.loadBrowse(next)
+}
+
+// startFilter opens the prompt for the browsed table's WHERE condition.
+func (sqline *Sqline) startFilter() {
+	sqline.filterView.SetFilter(sqline.browse.Table.Name, sqline.browse.Filter)
+	sqline.state = FilterView
+	sqline.mainView.SetStatus("Filter")
+	sqline.setInfo()
+}
+
+// closeFilter goes back to the data table.
+func (sqline *Sqline) closeFilter() {
+	sqline.state = MainView
+	sqline.mainView.SetState(views.DataTable)
+	sqline.setInfo()
+	screen.Fill(' ', defStyle)
+}
+
+// createFilterFunc reloads the first page with the new filter.
+func (sqline *Sqline) createFilterFunc() views.FilterFunc {
+	return func(filter string) error {
+		if sqline.cancelQuery != nil {
+			return ErrQueryRunning
+		}
+
+		next := *sqline.browse
+		next.SetFilter(filter)
+		sqline.closeFilter()
+		sqline.loadBrowse(next)
+		return nil
+	}
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/util"
+)
+
+var ErrDiffUsage = errors.New("usage: sqline diff [-o file] <from connection> <to connection>")
+
+// diffEvent carries a finished schema diff from the worker to the diff view.
+type diffEvent struct {
+	tcell.EventTime
+	diff     db.SchemaDiff
+	from, to util.DBEntry
+}
+
+// loadSchema connects to a saved connection just long enough to read its
+// tables. Cancelling ctx returns straight away, the connection is closed
+// once the schema queries that were already sent finish.
+func loadSchema(ctx context.Context, entry util.DBEntry) ([]db.Table, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	database, err := db.Open(entry.Driver, entry.ConnStr, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	type schema struct {
+		tables []db.Table
+		err    error
+	}
+
+	done := make(chan schema, 1)
+	go func() {
+		tables, err := database.GetTables()
+		database.Close()
+		done <- schema{tables, err}
+	}()
+
+	select {
+	case v := <-done:
+		return v.tables, v.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// diffConnections compares the schemas of two saved connections, the diff
+// holds the changes that make from match to.
+func diffConnections(ctx context.Context, from, to util.DBEntry) (db.SchemaDiff, error) {
+	fromTables, err := loadSchema(ctx, from)
+	if err != nil {
+		return db.SchemaDiff{}, fmt.Errorf("%s: %w", from.Name, err)
+	}
+
+	toTables, err := loadSchema(ctx, to)
+	if err != nil {
+		return db.SchemaDiff{}, fmt.Errorf("%s: %w", to.Name, err)
+	}
+
+	return db.DiffSchemas(fromTables, toTables), nil
+}
+
+func findConn(conns []util.DBEntry, name string) (util.DBEntry, error) {
+	for _, v := range conns {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+
+	return util.DBEntry{}, fmt.Errorf("no saved connection named %q", name)
+}
+
+// startDiff opens the connection list to pick the two connections to
+// compare, the first one picked is the one the migration is written for.
+func (sqline *Sqline) startDiff() {
+	if len(sqline.config.SavedConns) < 2 {
+		sqline.mainView.SetInfo([]rune("Save at least two connections to compare their schemas"))
+		return
+	}
+
+	sqline.diffFrom = nil
+	sqline.diffConnView.SetTitle("Select the connection to migrate")
+	sqline.state = DiffConnView
+	sqline.mainView.SetStatus("Diff")
+	sqline.setInfo()
+}
+
+func (sqline *Sqline) createDiffSelectFunc() func(util.DBEntry) {
+	return func(dbEntry util.DBEntry) {
+		if sqline.diffFrom == nil {
+			sqline.diffFrom = &dbEntry
+			sqline.diffConnView.SetTitle(fmt.Sprintf("Compare %s against", dbEntry.Name))
+			return
+		}
+
+		from, to := *sqline.diffFrom, dbEntry
+		sqline.diffFrom = nil
+		sqline.state = NormalMode
+		sqline.mainView.SetStatus("Normal")
+		sqline.setInfo()
+		screen.Fill(' ', defStyle)
+
+		sqline.runQuery(func(ctx context.Context) error {
+			diff, err := diffConnections(ctx, from, to)
+			if err != nil || ctx.Err() != nil {
+				return err
+			}
+
+			ev := &diffEvent{diff: diff, from: from, to: to}
+			ev.SetEventNow()
+			sqline.screen.PostEventWait(ev)
+			return nil
+		})
+	}
+}
+
+func (sqline *Sqline) showDiff(ev *diffEvent) {
+	sqline.diffView.SetDiff(ev.diff, ev.from.Name, ev.to.Name, ev.diff.MigrationSQL(ev.from.Driver))
+	sqline.state = DiffView
+	sqline.mainView.SetStatus("Diff")
+	sqline.setInfo()
+}
+
+// RunDiff compares the schemas of two saved connections without starting
+// the UI, the migration DDL for the first connection is written to the -o
+// file or stdout and a summary of the differences to stderr.
+func RunDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	out := flags.String("o", "", "file to write the migration DDL to, defaults to stdout")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return ErrDiffUsage
+	}
+
+	conf, err := util.LoadConf()
+	if err != nil {
+		return err
+	}
+
+	from, err := findConn(conf.SavedConns, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	to, err := findConn(conf.SavedConns, flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	diff, err := diffConnections(context.Background(), from, to)
+	if err != nil {
+		return err
+	}
+
+	writeDiffSummary(os.Stderr, diff, from.Name, to.Name)
+
+	sql := diff.MigrationSQL(from.Driver)
+	if *out == "" {
+		_, err = io.WriteString(os.Stdout, sql)
+		return err
+	}
+
+	return os.WriteFile(*out, []byte(sql), 0644)
+}
+
+func writeDiffSummary(w io.Writer, diff db.SchemaDiff, from, to string) {
+	if diff.Empty() {
+		fmt.Fprintf(w, "No differences, %s matches %s\n", from, to)
+		return
+	}
+
+	for _, v := range diff.Tables {
+		fmt.Fprintln(w, v.Summary())
+		if v.Kind != db.Changed {
+			continue
+		}
+
+		for _, col := range v.Columns {
+			fmt.Fprintln(w, "  "+col.Summary())
+		}
+		for _, index := range v.Indexes {
+			fmt.Fprintln(w, "  "+index.Summary())
+		}
+		for _, fk := range v.ForeignKeys {
+			fmt.Fprintln(w, "  "+fk.Summary())
+		}
+	}
+}
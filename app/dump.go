@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/util"
+	"github.com/sleepy-day/sqline/views"
+)
+
+var ErrDumpUsage = errors.New("usage: sqline dump [-o file] <connection>")
+
+func (sqline *Sqline) startDump() {
+	if sqline.database == nil {
+		sqline.mainView.SetInfo([]rune("Not connected to a database"))
+		return
+	}
+
+	sqline.state = DumpView
+	sqline.mainView.SetStatus("Dump")
+	sqline.setInfo()
+}
+
+// createDumpFunc creates the file straight away so a bad path is shown in
+// the form, the dump itself is written on the worker.
+func (sqline *Sqline) createDumpFunc() views.DumpFunc {
+	return func(path string) error {
+		switch {
+		case sqline.database == nil:
+			return ErrNotConnected
+		case sqline.cancelQuery != nil:
+			return ErrQueryRunning
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		database := sqline.database
+		sqline.state = NormalMode
+		sqline.mainView.SetStatus("Normal")
+		sqline.setInfo()
+		screen.Fill(' ', defStyle)
+
+		sqline.runQuery(func(ctx context.Context) error {
+			tables := 0
+			progress := func(table string, done, total int) {
+				tables = total
+				if done == total {
+					return
+				}
+				sqline.mainView.SetProgress([]rune(fmt.Sprintf("Dumping %s (%d/%d tables)", table, done+1, total)))
+			}
+
+			err := db.Dump(ctx, f, database, progress)
+			closeErr := f.Close()
+			if err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return err
+			}
+
+			sqline.postFunc()(func() {
+				sqline.mainView.SetInfo([]rune(fmt.Sprintf("Dumped %d tables to %s", tables, path)))
+			})
+			return nil
+		})
+
+		return nil
+	}
+}
+
+// RunDump writes a dump of a saved connection without starting the UI, to
+// the -o file or stdout.
+func RunDump(args []string) error {
+	flags := flag.NewFlagSet("dump", flag.ContinueOnError)
+	out := flags.String("o", "", "file to write the dump to, defaults to stdout")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return ErrDumpUsage
+	}
+
+	conf, err := util.LoadConf()
+	if err != nil {
+		return err
+	}
+
+	entry, err := findConn(conf.SavedConns, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Open(entry.Driver, entry.ConnStr, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if *out == "" {
+		return db.Dump(context.Background(), os.Stdout, database, nil)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+
+	err = db.Dump(context.Background(), f, database, nil)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*out)
+	}
+
+	return err
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/views"
+)
+
+// cellEdit is the cell being edited in the data table and the statement
+// waiting to be confirmed.
+type cellEdit struct {
+	target  *db.EditTarget
+	driver  string
+	row     db.Row
+	rowIdx  int
+	col     int
+	value   db.Value
+	pending db.BoundStatement
+}
+
+// startCellEdit opens the editor for the selected cell if the result can be
+// written back, otherwise the reason it can't is shown.
+func (sqline *Sqline) startCellEdit() {
+	if sqline.database == nil {
+		sqline.mainView.SetInfo([]rune("Not connected to a database"))
+		return
+	}
+
+	if sqline.cancelQuery != nil {
+		sqline.mainView.SetInfo([]rune("A query is already running"))
+		return
+	}
+
+	rs, ok := sqline.mainView.TableSource().(*db.ResultSet)
+	if !ok || rs.Statement() == "" {
+		sqline.mainView.SetInfo([]rune("These results can't be edited, only rows from a single SELECT can be"))
+		return
+	}
+
+	rowIdx, col, ok := sqline.mainView.SelectedCell()
+	if !ok {
+		sqline.mainView.SetInfo([]rune("Select a cell to edit"))
+		return
+	}
+
+	driver, _ := sqline.database.Info()
+	target, err := db.FindEditTarget(driver, rs.Statement(), sqline.tables, rs.Columns())
+	if err == nil {
+		err = target.Editable(col, rs.Columns())
+	}
+	if err != nil {
+		sqline.mainView.SetInfo([]rune("Not editable: " + err.Error()))
+		return
+	}
+
+	_, rows, _ := sqline.mainView.Result()
+	sqline.cellEdit = &cellEdit{
+		target: target,
+		driver: driver,
+		row:    rows[rowIdx],
+		rowIdx: rowIdx,
+		col:    col,
+	}
+
+	var value *string
+	if cell := rows[rowIdx][col]; !cell.IsNull() {
+		text := cell.String()
+		value = &text
+	}
+
+	sqline.cellEditView.SetCell(target.Table.Name, target.Columns[col], value)
+	sqline.state = CellEditView
+	sqline.mainView.SetStatus("Edit")
+	sqline.setInfo()
+}
+
+// createPrepareEditFunc builds the UPDATE for the new value and hands back
+// the SQL to confirm.
+func (sqline *Sqline) createPrepareEditFunc() views.PrepareEditFunc {
+	return func(value *string) (string, error) {
+		edit := sqline.cellEdit
+		stmt, err := edit.target.Update(edit.driver, edit.row, edit.col, value)
+		if err != nil {
+			return "", err
+		}
+
+		edit.pending = stmt
+		edit.value = db.Value{}
+		if value != nil {
+			edit.value = db.TextVal(*value)
+		}
+
+		return stmt.String(), nil
+	}
+}
+
+// createConfirmEditFunc runs the confirmed UPDATE on the worker, the cell is
+// only changed in the data table once the row has been updated.
+func (sqline *Sqline) createConfirmEditFunc() views.ConfirmFunc {
+	return func() error {
+		if sqline.cancelQuery != nil {
+			return ErrQueryRunning
+		}
+
+		edit := sqline.cellEdit
+		database := sqline.database
+		sqline.state = MainView
+		sqline.mainView.SetState(views.DataTable)
+		sqline.setInfo()
+		screen.Fill(' ', defStyle)
+
+		sqline.runQuery(func(ctx context.Context) error {
+			affected, err := edit.pending.Exec(ctx, database)
+			if err != nil {
+				return err
+			}
+
+			sqline.postFunc()(func() {
+				switch affected {
+				case 0:
+					sqline.mainView.SetInfo([]rune("No rows were changed, the row may have been deleted since it was read or already had that value"))
+				case 1:
+					sqline.mainView.SetCell(edit.rowIdx, edit.col, edit.value)
+					sqline.mainView.SetInfo([]rune(fmt.Sprintf("Updated %s.%s", edit.target.Table.Name, edit.target.Columns[edit.col])))
+				default:
+					sqline.mainView.SetCell(edit.rowIdx, edit.col, edit.value)
+					sqline.mainView.SetInfo([]rune(fmt.Sprintf("%d rows were updated", affected)))
+				}
+			})
+			return nil
+		})
+
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/views"
+)
+
+var ErrNoResults = errors.New("no results to export")
+
+// startExport opens the export form if the data table is showing rows.
+func (sqline *Sqline) startExport() {
+	columns, _, _ := sqline.mainView.Result()
+	if columns == nil {
+		sqline.mainView.SetInfo([]rune("No results to export, run a query first"))
+		return
+	}
+
+	sqline.state = ExportView
+	sqline.mainView.SetStatus("Export")
+	sqline.setInfo()
+}
+
+// createExportFunc writes the rows loaded in the data table to a file, rows
+// that haven't been fetched yet aren't included.
+func (sqline *Sqline) createExportFunc() views.ExportFunc {
+	return func(format db.ExportFormat, path, table string) error {
+		columns, rows, done := sqline.mainView.Result()
+		if columns == nil {
+			return ErrNoResults
+		}
+
+		if format == db.InsertExport && strings.TrimSpace(table) == "" {
+			return db.ErrExportTable
+		}
+
+		var driver string
+		if sqline.database != nil {
+			driver, _ = sqline.database.Info()
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		err = db.Export(f, format, columns, rows, table, driver)
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			return err
+		}
+
+		msg := fmt.Sprintf("Exported %d rows to %s as %s", len(rows), path, format)
+		if !done {
+			msg += ", rows that weren't fetched yet were left out"
+		}
+
+		sqline.state = NormalMode
+		sqline.mainView.SetStatus("Normal")
+		screen.Fill(' ', defStyle)
+		sqline.mainView.SetInfo([]rune(msg))
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/util"
+	"github.com/sleepy-day/sqline/views"
+)
+
+// loadHistory reads the history file, if its path can't be found history is
+// only kept until sqline exits.
+func (sqline *Sqline) loadHistory() error {
+	path, err := util.HistoryPath()
+	if err != nil {
+		return err
+	}
+
+	sqline.historyPath = path
+	sqline.history, err = util.LoadHistory(path)
+	return err
+}
+
+// createHistoryFunc records the statements run on the connection named conn,
+// the file is written on the worker and the entries are added to the
+// history view on the event loop. A SELECT whose rows are still being read
+// is recorded straight away and its row count is filled in once they have
+// been.
+func (sqline *Sqline) createHistoryFunc(conn string) db.HistoryFunc {
+	return func(results []db.StatementResult) {
+		var entries []util.HistoryEntry
+		streaming := make(map[int]*db.ResultSet)
+		for i, v := range results {
+			entry := util.HistoryEntry{
+				Connection: conn,
+				Statement:  v.Statement,
+				RanAt:      v.Start,
+				Duration:   v.Duration,
+				Rows:       v.Affected(),
+			}
+			if v.Err != nil {
+				entry.Error = v.Err.Error()
+			}
+			if v.Rows != nil && entry.Rows < 0 {
+				streaming[i] = v.Rows
+			}
+
+			entries = append(entries, entry)
+		}
+
+		var err error
+		if sqline.historyPath != "" {
+			err = util.AppendHistory(sqline.historyPath, entries...)
+		}
+
+		sqline.postFunc()(func() {
+			sqline.history = append(sqline.history, entries...)
+			if len(sqline.history) > util.MaxHistory {
+				sqline.history = sqline.history[len(sqline.history)-util.MaxHistory:]
+			}
+
+			if err != nil {
+				sqline.handleError(err)
+			}
+		})
+
+		for i, rows := range streaming {
+			entry := entries[i]
+			rows.WhenFinished(func(count int64) {
+				if count >= 0 {
+					entry.Rows = count
+					sqline.updateHistory(entry)
+				}
+			})
+		}
+	}
+}
+
+// updateHistory replaces the entry that ran at the same time as entry with
+// it, in the file and then in the history on the event loop.
+func (sqline *Sqline) updateHistory(entry util.HistoryEntry) {
+	var err error
+	if sqline.historyPath != "" {
+		err = util.UpdateHistory(sqline.historyPath, entry)
+	}
+
+	sqline.postFunc()(func() {
+		for i := len(sqline.history) - 1; i >= 0; i-- {
+			v := sqline.history[i]
+			if v.Connection == entry.Connection && v.Statement == entry.Statement && v.RanAt.Equal(entry.RanAt) {
+				sqline.history[i] = entry
+				break
+			}
+		}
+
+		if err != nil {
+			sqline.handleError(err)
+		}
+	})
+}
+
+func (sqline *Sqline) startHistory() {
+	sqline.historyView.SetEntries(sqline.history)
+	sqline.state = HistoryView
+	sqline.mainView.SetStatus("History")
+	sqline.setInfo()
+}
+
+// createPasteFunc puts a statement from the history or a snippet at the
+// editor's cursor and switches to the editor.
+func (sqline *Sqline) createPasteFunc() views.PasteFunc {
+	return func(statement string) {
+		sqline.mainView.InsertText([]rune(statement))
+		sqline.state = Editor
+		sqline.mainView.SetState(views.Editor)
+		sqline.setInfo()
+		screen.Fill(' ', defStyle)
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/views"
+)
+
+var (
+	ErrNotConnected = errors.New("not connected to a database")
+	ErrQueryRunning = errors.New("a query is already running")
+)
+
+// startImport opens the import form with the connection's tables to append
+// to.
+func (sqline *Sqline) startImport() {
+	if sqline.database == nil {
+		sqline.mainView.SetInfo([]rune("Not connected to a database"))
+		return
+	}
+
+	if sqline.cancelQuery != nil {
+		sqline.mainView.SetInfo([]rune("A query is already running"))
+		return
+	}
+
+	driver, _ := sqline.database.Info()
+	sqline.importView.SetTables(sqline.tables, driver)
+	sqline.state = ImportView
+	sqline.mainView.SetStatus("Import")
+	sqline.setInfo()
+}
+
+func createImportLoadFunc() views.ImportLoadFunc {
+	return func(path string, format db.ExportFormat) (*db.ImportData, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return db.ReadDelimited(f, format)
+	}
+}
+
+// createImportFunc closes the form and inserts the rows on the worker, the
+// status bar counts the rows written so far and the trees are refreshed
+// once it's done.
+func (sqline *Sqline) createImportFunc() views.ImportFunc {
+	return func(data *db.ImportData, plan db.ImportPlan) error {
+		switch {
+		case sqline.database == nil:
+			return ErrNotConnected
+		case sqline.txStatus.Open:
+			return db.ErrImportTransaction
+		case sqline.cancelQuery != nil:
+			return ErrQueryRunning
+		}
+
+		database := sqline.database
+		sqline.state = NormalMode
+		sqline.mainView.SetStatus("Normal")
+		sqline.setInfo()
+		screen.Fill(' ', defStyle)
+
+		sqline.runQuery(func(ctx context.Context) error {
+			progress := func(done, total int) {
+				sqline.mainView.SetProgress([]rune(fmt.Sprintf("Importing %d/%d rows", done, total)))
+			}
+
+			err := db.Import(ctx, database, data, plan, progress)
+			if err != nil {
+				return err
+			}
+
+			tables, err := database.GetTables()
+			if err == nil {
+				sqline.postTablesFunc()(tables)
+			}
+
+			sqline.postFunc()(func() {
+				sqline.mainView.SetInfo([]rune(fmt.Sprintf("Imported %d rows into %s", len(data.Rows), plan.Table)))
+			})
+			return nil
+		})
+
+		return nil
+	}
+}
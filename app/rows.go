@@ -0,0 +1,199 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/views"
+)
+
+// rowDelete is the DELETE waiting to be confirmed and the data table rows it
+// removes.
+type rowDelete struct {
+	table string
+	rows  []int
+	stmt  db.BoundStatement
+}
+
+// startInsertRow opens the insert form for the table selected in the table
+// tree.
+func (sqline *Sqline) startInsertRow() {
+	if sqline.database == nil {
+		sqline.mainView.SetInfo([]rune("Not connected to a database"))
+		return
+	}
+
+	table, ok := sqline.mainView.SelectedTable()
+	if !ok {
+		sqline.mainView.SetInfo([]rune("Select a table to insert a row into"))
+		return
+	}
+
+	sqline.insertTable = table
+	sqline.insertRowView.SetTable(table)
+	sqline.state = InsertRowView
+	sqline.mainView.SetStatus("Insert")
+	sqline.setInfo()
+}
+
+// closeInsertRow goes back to the table tree.
+func (sqline *Sqline) closeInsertRow() {
+	sqline.state = MainView
+	sqline.mainView.SetState(views.TblList)
+	sqline.setInfo()
+	screen.Fill(' ', defStyle)
+}
+
+// createInsertFunc runs the INSERT for the form's values on the worker.
+func (sqline *Sqline) createInsertFunc() views.InsertFunc {
+	return func(values map[string]*string) error {
+		if sqline.cancelQuery != nil {
+			return ErrQueryRunning
+		}
+
+		driver, _ := sqline.database.Info()
+		table := sqline.insertTable
+		stmt := db.InsertRow(driver, table, values)
+		database := sqline.database
+		sqline.closeInsertRow()
+
+		sqline.runQuery(func(ctx context.Context) error {
+			if _, err := stmt.Exec(ctx, database); err != nil {
+				return err
+			}
+
+			sqline.postFunc()(func() {
+				sqline.mainView.SetInfo([]rune("Inserted a row into " + table.Name))
+			})
+			return nil
+		})
+
+		return nil
+	}
+}
+
+// startDeleteRows builds the DELETE for the marked rows of the data table,
+// or the selected row if none are marked, and shows it for confirmation.
+func (sqline *Sqline) startDeleteRows() {
+	if sqline.database == nil {
+		sqline.mainView.SetInfo([]rune("Not connected to a database"))
+		return
+	}
+
+	if sqline.cancelQuery != nil {
+		sqline.mainView.SetInfo([]rune("A query is already running"))
+		return
+	}
+
+	rs, ok := sqline.mainView.TableSource().(*db.ResultSet)
+	if !ok || rs.Statement() == "" {
+		sqline.mainView.SetInfo([]rune("Rows can only be deleted from the results of a single SELECT"))
+		return
+	}
+
+	marked := sqline.mainView.MarkedRows()
+	if len(marked) == 0 {
+		row, _, ok := sqline.mainView.SelectedCell()
+		if !ok {
+			sqline.mainView.SetInfo([]rune("Select or mark the rows to delete"))
+			return
+		}
+		marked = []int{row}
+	}
+
+	driver, _ := sqline.database.Info()
+	target, err := db.FindEditTarget(driver, rs.Statement(), sqline.tables, rs.Columns())
+	if err != nil {
+		sqline.mainView.SetInfo([]rune("Can't delete: " + err.Error()))
+		return
+	}
+
+	_, rows, _ := sqline.mainView.Result()
+	var selected []db.Row
+	for _, v := range marked {
+		selected = append(selected, rows[v])
+	}
+
+	stmt, err := target.Delete(driver, selected)
+	if err != nil {
+		sqline.mainView.SetInfo([]rune("Can't delete: " + err.Error()))
+		return
+	}
+
+	sqline.rowDelete = &rowDelete{
+		table: target.Table.Name,
+		rows:  marked,
+		stmt:  stmt,
+	}
+
+	sqline.confirmView.SetStatement(fmt.Sprintf("Delete %d rows from %s?", len(marked), target.Table.Name), stmt.String())
+	sqline.state = ConfirmView
+	sqline.mainView.SetStatus("Delete")
+	sqline.setInfo()
+}
+
+// closeConfirm goes back to the data table.
+func (sqline *Sqline) closeConfirm() {
+	sqline.state = MainView
+	sqline.mainView.SetState(views.DataTable)
+	sqline.setInfo()
+	screen.Fill(' ', defStyle)
+}
+
+// createConfirmDeleteFunc runs the confirmed DELETE on the worker, the rows
+// are taken out of the data table once it succeeds.
+func (sqline *Sqline) createConfirmDeleteFunc() views.ConfirmFunc {
+	return func() error {
+		if sqline.cancelQuery != nil {
+			return ErrQueryRunning
+		}
+
+		del := sqline.rowDelete
+		database := sqline.database
+		sqline.closeConfirm()
+
+		sqline.runQuery(func(ctx context.Context) error {
+			affected, err := del.stmt.Exec(ctx, database)
+			if err != nil {
+				return err
+			}
+
+			sqline.postFunc()(func() {
+				sqline.mainView.RemoveRows(del.rows)
+				sqline.mainView.SetInfo([]rune(fmt.Sprintf("Deleted %d rows from %s", affected, del.table)))
+			})
+			return nil
+		})
+
+		return nil
+	}
+}
+
+// nextResultSet shows the next result set from the batch behind the data
+// table once the current one has been read to the end.
+func (sqline *Sqline) nextResultSet() {
+	rs, ok := sqline.mainView.TableSource().(*db.ResultSet)
+	if !ok || !rs.HasNextSet() {
+		sqline.mainView.SetInfo([]rune("There are no more result sets"))
+		return
+	}
+
+	if !rs.Done() {
+		sqline.mainView.SetInfo([]rune("Fetch the rest of this result set before moving to the next"))
+		return
+	}
+
+	next, err := rs.NextSet()
+	if err != nil {
+		sqline.handleError(err)
+		return
+	}
+
+	if next == nil {
+		sqline.mainView.SetInfo([]rune("There are no more result sets"))
+		return
+	}
+
+	sqline.mainView.TableFunc()(next, nil)
+}
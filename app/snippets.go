@@ -0,0 +1,71 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/util"
+	"github.com/sleepy-day/sqline/views"
+)
+
+var ErrNoSavedConnection = errors.New("the current connection isn't a saved connection")
+
+func (sqline *Sqline) startSnippets() {
+	sqline.snippetView.SetSnippets(sqline.config.SnippetsFor(sqline.connName))
+	sqline.state = SnippetView
+	sqline.mainView.SetStatus("Snippets")
+	sqline.setInfo()
+}
+
+// createSnippetFunc keeps the selection from the editor and opens the form
+// that names it.
+func (sqline *Sqline) createSnippetFunc() components.ExecSQLFunc {
+	return func(text []rune) error {
+		sqline.mainView.SetState(views.Editor)
+		if strings.TrimSpace(string(text)) == "" {
+			sqline.mainView.SetInfo([]rune("Nothing selected to save as a snippet"))
+			return nil
+		}
+
+		sqline.pendingSnippet = string(text)
+		sqline.state = SaveSnippetView
+		sqline.mainView.SetStatus("SaveSnippet")
+		sqline.setInfo()
+		return nil
+	}
+}
+
+// createSaveSnippetFunc writes the pending snippet to the config file and goes
+// back to the editor.
+func (sqline *Sqline) createSaveSnippetFunc() views.SaveSnippetFunc {
+	return func(name string, global bool) error {
+		conn := ""
+		if !global {
+			if sqline.connName == "" {
+				return ErrNoSavedConnection
+			}
+			conn = sqline.connName
+		}
+
+		snippet := util.Snippet{Name: name, SQL: sqline.pendingSnippet}
+		err := sqline.config.AddSnippet(conn, snippet)
+		if err != nil {
+			return err
+		}
+
+		err = util.SaveConf(sqline.config)
+		if err != nil {
+			return err
+		}
+
+		sqline.pendingSnippet = ""
+		sqline.state = Editor
+		sqline.mainView.SetState(views.Editor)
+		sqline.setInfo()
+		screen.Fill(' ', defStyle)
+		sqline.mainView.SetInfo([]rune(fmt.Sprintf("Snippet %s saved", strings.TrimSpace(name))))
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestBrowse(t *testing.T) {
+	lite := createSchema(t, `
+		CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT, age INTEGER);
+		INSERT INTO people VALUES (1, 'Ann', 30), (2, 'Bob', 25), (3, 'Cat', 41), (4, 'Dan', 25), (5, 'Eve', 19);
+	`)
+	tables := getTables(t, lite)
+
+	var people db.Table
+	for _, v := range tables {
+		if v.Name == "people" {
+			people = v
+		}
+	}
+
+	browse := db.Browse{Table: people, PageSize: 2}
+	page := func() string {
+		t.Helper()
+
+		rs := selectResult(t, lite, browse.Query("sqlite3"))
+		if _, err := db.FindEditTarget("sqlite3", rs.Statement(), tables, rs.Columns()); err != nil {
+			t.Fatalf("expected the page to be editable: %v", err)
+		}
+
+		var ids string
+		for _, v := range readRows(t, rs)[1:] {
+			ids += string(v[0])
+		}
+		return ids
+	}
+
+	tests := []struct {
+		change   func()
+		expected string
+	}{
+		{func() {}, "12"},
+		{func() { browse.Page = 2 }, "5"},
+		{func() { browse.SortBy("age") }, "52"},
+		{func() { browse.Page = 1 }, "41"},
+		{func() { browse.SortBy("age") }, "31"},
+		{func() { browse.SetFilter(" age < 30 ") }, "24"},
+		{func() { browse.SetFilter("") }, "31"},
+	}
+
+	for i, tt := range tests {
+		tt.change()
+		if got := page(); got != tt.expected {
+			t.Fatalf("step %d: expected ids %s, got %s from %s", i, tt.expected, got, browse.Query("sqlite3"))
+		}
+	}
+
+	browse = db.Browse{Table: people, PageSize: 50, Page: 1, Filter: "name LIKE 'A%'"}
+	if sql := browse.Query("sqlserver"); sql != "SELECT * FROM [people] WHERE (name LIKE 'A%') ORDER BY [id] OFFSET 50 ROWS FETCH NEXT 50 ROWS ONLY" {
+		t.Fatalf("unexpected sqlserver query %s", sql)
+	}
+
+	browse = db.Browse{Table: db.Table{Name: "dbo.logs"}, PageSize: 10}
+	if sql := browse.Query("sqlserver"); sql != "SELECT * FROM [dbo].[logs] ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY" {
+		t.Fatalf("unexpected sqlserver query %s", sql)
+	}
+	if sql := browse.Query("mysql"); sql != "SELECT * FROM `dbo.logs` LIMIT 10 OFFSET 0" {
+		t.Fatalf("unexpected mysql query %s", sql)
+	}
+
+	browse = db.Browse{Table: people, PageSize: 2}
+	for _, filter := range []string{"1=1); DELETE FROM people; SELECT (1", "age > 1; DELETE FROM people", "1=1) OR (1=1", "(age > 1"} {
+		browse.SetFilter(filter)
+		if _, err := browse.Select(context.Background(), lite); err != db.ErrBrowseFilter {
+			t.Fatalf("expected %q to be refused, got %v", filter, err)
+		}
+	}
+
+	browse.SetFilter("name = 'a;b' OR (age > 40)")
+	rs, err := browse.Select(context.Background(), lite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.Statement() != browse.Query("sqlite3") {
+		t.Fatalf("expected the page to keep its statement, got %q", rs.Statement())
+	}
+	if rows := readRows(t, rs); len(rows) != 2 || string(rows[1][0]) != "3" {
+		t.Fatalf("unexpected rows for the filter %q", rows)
+	}
+
+	if rows := readRows(t, selectResult(t, lite, "SELECT count(*) FROM people")); string(rows[1][0]) != "5" {
+		t.Fatalf("the refused filters changed the table, %s rows left", string(rows[1][0]))
+	}
+}
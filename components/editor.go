@@ -18,6 +18,9 @@ type editorMode byte
 
 type ExecSQLFunc func([]rune) error
 
+// StatementFunc finds the statement in script that contains the rune offset.
+type StatementFunc func(script string, offset int) string
+
 const (
 	normal editorMode = iota
 	insert
@@ -46,6 +49,9 @@ type Editor struct {
 
 	style, hlStyle *tcell.Style
 	execSQLFunc    ExecSQLFunc
+	explainFunc    ExecSQLFunc
+	snippetFunc    ExecSQLFunc
+	statementFunc  StatementFunc
 	mode           editorMode
 	hlLine         bool
 }
@@ -148,18 +154,20 @@ func (edit *Editor) HandleInput(ev *tcell.EventKey) {
 			edit.moveRight()
 		case tcell.KeyEnter:
 			edit.execSQL()
+		case tcell.KeyRune:
+			switch ev.Rune() {
+			case 'P':
+				edit.explain()
+			case 'S':
+				edit.saveSnippet()
+				return
+			}
 		case tcell.KeyHome:
 			edit.moveToLineStart()
 		case tcell.KeyEnd:
 			edit.moveToLineEnd()
 		case tcell.KeyEsc:
-			edit.mode = normal
-			edit.hlLine = false
-			edit.hlStartPos = -1
-			edit.hlEndPos = -1
-			edit.hlStartLn = -1
-			edit.hlEndLn = -1
-
+			edit.clearSelection()
 			return
 		}
 
@@ -187,6 +195,8 @@ func (edit *Editor) HandleInput(ev *tcell.EventKey) {
 			switch ev.Rune() {
 			case 'i':
 				edit.mode = insert
+			case 'P':
+				edit.explain()
 			case 'V':
 				if edit.mode != normal {
 					break
@@ -254,6 +264,62 @@ func (edit *Editor) execSQL() {
 		return
 	}
 
+	text, err := edit.selectedText()
+	if err != nil {
+		// TODO: get error handling func
+		return
+	}
+
+	err = edit.execSQLFunc(text)
+	if err != nil {
+		// here too
+		return
+	}
+}
+
+// explain passes the selection in visual mode, or the statement under the
+// cursor in normal mode, to the explain func.
+func (edit *Editor) explain() {
+	if edit.explainFunc == nil {
+		return
+	}
+
+	var text []rune
+	if edit.mode == visual {
+		var err error
+		text, err = edit.selectedText()
+		if err != nil {
+			return
+		}
+	} else {
+		text = edit.CurrentStatement()
+	}
+
+	edit.explainFunc(text)
+}
+
+// saveSnippet leaves visual mode and passes the selection to the snippet
+// func.
+func (edit *Editor) saveSnippet() {
+	text, err := edit.selectedText()
+	edit.clearSelection()
+	if err != nil || edit.snippetFunc == nil {
+		return
+	}
+
+	edit.snippetFunc(text)
+}
+
+func (edit *Editor) clearSelection() {
+	edit.mode = normal
+	edit.hlLine = false
+	edit.hlStartPos = -1
+	edit.hlEndPos = -1
+	edit.hlStartLn = -1
+	edit.hlEndLn = -1
+}
+
+func (edit *Editor) selectedText() ([]rune, error) {
 	stLn, stPos := edit.hlStartLn, edit.hlStartPos
 	enLn, enPos := edit.hlEndLn, edit.hlEndPos
 	if edit.hlLine {
@@ -278,21 +344,31 @@ func (edit *Editor) execSQL() {
 		}
 	}
 
-	text, err := edit.gap.GetTextInRange(
+	return edit.gap.GetTextInRange(
 		util.Pos{Line: stLn, Col: stPos},
 		util.Pos{Line: enLn, Col: enPos},
 	)
+}
 
-	if err != nil {
-		// TODO: get error handling func
-		return
+// CurrentStatement returns the statement the cursor is in.
+func (edit *Editor) CurrentStatement() []rune {
+	lines := edit.gap.GetLines(0, edit.gap.Lines()+1)
+	cursorLn := edit.curY + edit.lineOffset
+
+	var text []rune
+	offset := 0
+	for i, v := range lines {
+		if i == cursorLn {
+			offset = len(text) + min(edit.curX, len(v))
+		}
+		text = append(text, v...)
 	}
 
-	err = edit.execSQLFunc(text)
-	if err != nil {
-		// here too
-		return
+	if edit.statementFunc == nil {
+		return text
 	}
+
+	return []rune(edit.statementFunc(string(text), offset))
 }
 
 func (edit *Editor) insertChar(ch rune) {
@@ -305,6 +381,21 @@ func (edit *Editor) insertChar(ch rune) {
 	edit.move(true)
 }
 
+// InsertText inserts text at the cursor as if it had been typed.
+func (edit *Editor) InsertText(text []rune) {
+	for _, ch := range text {
+		switch ch {
+		case '\r':
+		case '\n':
+			edit.insertNewLine()
+		case '\t':
+			edit.insertTab()
+		default:
+			edit.insertChar(ch)
+		}
+	}
+}
+
 func (edit *Editor) highlight() {
 
 }
@@ -702,6 +793,20 @@ func (edit *Editor) SetSQLFunc(fn ExecSQLFunc) {
 	edit.execSQLFunc = fn
 }
 
+func (edit *Editor) SetExplainFunc(fn ExecSQLFunc) {
+	edit.explainFunc = fn
+}
+
+func (edit *Editor) SetSnippetFunc(fn ExecSQLFunc) {
+	edit.snippetFunc = fn
+}
+
+// SetStatementFunc sets how the statement under the cursor is found in the
+// text, it depends on the syntax of the connection's database.
+func (edit *Editor) SetStatementFunc(fn StatementFunc) {
+	edit.statementFunc = fn
+}
+
 func (edit *Editor) ClearSQLFunc() {
 	edit.execSQLFunc = nil
 }
@@ -43,6 +43,15 @@ func CreateList[T any](left, top, right, bottom int, listItems []ListItem[T], ti
 
 func (list *List[T]) SetList(items []ListItem[T]) {
 	list.listItems = items
+	list.offset = 0
+	list.selected = 0
+	if len(items) == 0 {
+		list.selected = -1
+	}
+}
+
+func (list *List[T]) SetTitle(title []rune) {
+	list.window.SetTitle(title)
 }
 
 func (list *List[T]) SelectedItem() *ListItem[T] {
@@ -58,7 +58,7 @@ func (rs *RadioSelect) HandleInput(ev *tcell.EventKey) {
 }
 
 func (rs *RadioSelect) GetSelection() string {
-	if rs.selected <= 0 {
+	if rs.selected < 0 {
 		return ""
 	}
 
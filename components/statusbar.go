@@ -1,6 +1,7 @@
 package components
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -13,21 +14,29 @@ type StatusBar struct {
 	status             []rune
 	info               []rune
 	style, statusStyle *tcell.Style
+	runningStyle       tcell.Style
+	txStyle            tcell.Style
+	running            bool
+	runStart           time.Time
+	progress           []rune
+	tx                 []rune
 	mu                 *sync.Mutex
 }
 
 func CreateStatusBar(left, top, right, popUpHeight int, initStatus []rune, style, statusStyle *tcell.Style) *StatusBar {
 	return &StatusBar{
-		left:        left,
-		top:         top,
-		right:       right,
-		width:       right - left - 1,
-		popUpHeight: popUpHeight,
-		status:      initStatus,
-		info:        []rune{},
-		style:       style,
-		statusStyle: statusStyle,
-		mu:          &sync.Mutex{},
+		left:         left,
+		top:          top,
+		right:        right,
+		width:        right - left - 1,
+		popUpHeight:  popUpHeight,
+		status:       initStatus,
+		info:         []rune{},
+		style:        style,
+		statusStyle:  statusStyle,
+		runningStyle: tcell.StyleDefault.Background(tcell.ColorYellow).Foreground(tcell.ColorBlack),
+		txStyle:      tcell.StyleDefault.Background(tcell.ColorMaroon).Foreground(tcell.ColorWhite),
+		mu:           &sync.Mutex{},
 	}
 }
 
@@ -38,6 +47,17 @@ func (sb *StatusBar) Render(screen tcell.Screen) {
 	statusWidth := len(sb.status) + 1
 	infoStart := 16
 
+	var running []rune
+	if sb.running {
+		elapsed := time.Since(sb.runStart).Truncate(100 * time.Millisecond)
+		running = []rune(fmt.Sprintf(" Running %s (Ctrl-C to cancel) ", elapsed))
+		if len(sb.progress) > 0 {
+			running = []rune(fmt.Sprintf(" %s, %s (Ctrl-C to cancel) ", string(sb.progress), elapsed))
+		}
+	}
+	runningStart := sb.width - len(running)
+	txStart := runningStart - len(sb.tx)
+
 	for i := range sb.width {
 		statusLeft := sb.left + i
 
@@ -49,6 +69,16 @@ func (sb *StatusBar) Render(screen tcell.Screen) {
 			continue
 		}
 
+		if len(running) > 0 && i >= runningStart {
+			screen.SetContent(statusLeft, sb.top, running[i-runningStart], nil, sb.runningStyle)
+			continue
+		}
+
+		if len(sb.tx) > 0 && i >= txStart && i < runningStart {
+			screen.SetContent(statusLeft, sb.top, sb.tx[i-txStart], nil, sb.txStyle)
+			continue
+		}
+
 		if i >= infoStart && i-infoStart < len(sb.info) {
 			screen.SetContent(statusLeft, sb.top, sb.info[i-infoStart], nil, *sb.style)
 			continue
@@ -71,6 +101,50 @@ func (sb *StatusBar) ClearInfo() {
 	sb.info = []rune{}
 }
 
+// SetRunning shows how long a query has been running for in the right hand
+// side of the status bar until StopRunning is called.
+func (sb *StatusBar) SetRunning(start time.Time) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	sb.running = true
+	sb.runStart = start
+}
+
+func (sb *StatusBar) StopRunning() {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	sb.running = false
+	sb.progress = nil
+}
+
+// SetProgress replaces "Running" in the timer with msg until StopRunning is
+// called, it can be called from the worker while the query runs.
+func (sb *StatusBar) SetProgress(msg []rune) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	sb.progress = msg
+}
+
+// SetTransaction shows whether a transaction is open and how many statements
+// it holds next to the running timer, when none is open it only shows that
+// autocommit is off.
+func (sb *StatusBar) SetTransaction(open bool, statements int, autocommit bool) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	switch {
+	case open:
+		sb.tx = []rune(fmt.Sprintf(" Transaction open: %d statements ", statements))
+	case !autocommit:
+		sb.tx = []rune(" Autocommit off ")
+	default:
+		sb.tx = nil
+	}
+}
+
 func (sb *StatusBar) SetErr(msg []rune) {
 	sb.info = msg
 	go func() {
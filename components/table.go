@@ -1,17 +1,46 @@
 package components
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
+	"github.com/sleepy-day/sqline/shared"
 )
 
-type TableDataFunc func([][][]rune, []rune)
+const tablePageSize = 200
+
+// RowSource supplies the rows shown in a Table, they're pulled a page at a
+// time with NextRows as the user scrolls towards the end of what's been
+// fetched.
+type RowSource interface {
+	ColumnNames() []string
+	NextRows(n int) ([]shared.Row, error)
+	Done() bool
+	Close() error
+}
+
+// truncatedSource is a RowSource that can be cut short before its last row.
+type truncatedSource interface {
+	Truncated() bool
+}
+
+type TableDataFunc func(RowSource, []rune)
+
+// PostFunc runs fn on the UI goroutine, the Table uses it to hand back pages
+// that were fetched in the background.
+type PostFunc func(fn func())
 
 type Table struct {
 	window, popUpWindow *Window
 	style               *tcell.Style
 	hlStyle             tcell.Style
+	nullStyle           tcell.Style
+	nullLightStyle      tcell.Style
 	oddRowStyle         tcell.Style
 	evenRowStyle        tcell.Style
+	markStyle           tcell.Style
 
 	left, top, right, bottom     int
 	pLeft, pTop, pRight, pBottom int
@@ -31,30 +60,41 @@ type Table struct {
 	prepared bool
 	scroll   bool
 	refresh  bool
+	fetching bool
+	stopped  bool
 
 	data      [][][]rune
+	rows      []shared.Row
+	marked    map[int]bool
 	resultMsg []rune
+	source    RowSource
+	fetchErr  error
+	post      PostFunc
 }
 
 func CreateTable(left, top, right, bottom, pLeft, pTop, pRight, pBottom, maxWidth int, data [][][]rune, style *tcell.Style) *Table {
 	t := &Table{
-		left:         left,
-		top:          top,
-		right:        right,
-		bottom:       bottom,
-		maxWidth:     maxWidth,
-		tableHeight:  bottom - top - 1,
-		expanded:     false,
-		sCol:         -1,
-		sRow:         -1,
-		anchorCol:    0,
-		style:        style,
-		oddRowStyle:  tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite),
-		evenRowStyle: tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite),
-		hlStyle:      tcell.StyleDefault.Background(tcell.ColorGreen).Foreground(tcell.ColorWhite),
-		window:       CreateWindow(left, top, right, bottom, 0, 0, true, true, nil, style),
-		popUpWindow:  CreateWindow(pLeft, pTop, pRight, pBottom, 0, 0, true, true, nil, style),
-		data:         data,
+		left:           left,
+		top:            top,
+		right:          right,
+		bottom:         bottom,
+		maxWidth:       maxWidth,
+		tableHeight:    bottom - top - 1,
+		expanded:       false,
+		sCol:           -1,
+		sRow:           -1,
+		anchorCol:      0,
+		style:          style,
+		oddRowStyle:    tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite),
+		evenRowStyle:   tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite),
+		hlStyle:        tcell.StyleDefault.Background(tcell.ColorGreen).Foreground(tcell.ColorWhite),
+		nullStyle:      tcell.StyleDefault.Foreground(tcell.ColorAqua).Italic(true),
+		nullLightStyle: tcell.StyleDefault.Foreground(tcell.ColorNavy).Italic(true),
+		markStyle:      tcell.StyleDefault.Background(tcell.ColorMaroon).Foreground(tcell.ColorWhite),
+		marked:         make(map[int]bool),
+		window:         CreateWindow(left, top, right, bottom, 0, 0, true, true, nil, style),
+		popUpWindow:    CreateWindow(pLeft, pTop, pRight, pBottom, 0, 0, true, true, nil, style),
+		data:           data,
 	}
 
 	t.popUpWidth = t.popUpWindow.GetUsableWidth()
@@ -64,32 +104,168 @@ func CreateTable(left, top, right, bottom, pLeft, pTop, pRight, pBottom, maxWidt
 }
 
 func (t *Table) CalculateColWidths() {
-	if len(t.data) > 0 && len(t.data[0]) > 0 {
-		t.colWidths = make([]int, len(t.data[0]))
+	t.colWidths = nil
+	t.scroll = false
+	t.growColWidths(t.data)
+}
 
-		totalWidth := 0
-		for col := range t.data[0] {
+// growColWidths widens the columns to fit rows, called with each new page so
+// the existing rows don't need to be measured again.
+func (t *Table) growColWidths(rows [][][]rune) {
+	if len(t.data) == 0 || len(t.data[0]) == 0 {
+		return
+	}
 
-			width := 0
-			for row := range t.data {
-				if len(t.data[row][col]) > t.maxWidth {
-					width = t.maxWidth
-					break
-				}
+	if len(t.colWidths) != len(t.data[0]) {
+		t.colWidths = make([]int, len(t.data[0]))
+	}
 
-				if len(t.data[row][col]) > width {
-					width = len(t.data[row][col])
-				}
+	totalWidth := 0
+	for col := range t.colWidths {
+		for row := range rows {
+			width := len(rows[row][col])
+			if width > t.maxWidth {
+				width = t.maxWidth
 			}
 
-			t.colWidths[col] = width
-			totalWidth += width
+			if width > t.colWidths[col] {
+				t.colWidths[col] = width
+			}
 		}
 
-		if totalWidth > t.right-t.left {
-			t.scroll = true
+		totalWidth += t.colWidths[col]
+	}
+
+	if totalWidth > t.right-t.left {
+		t.scroll = true
+	}
+}
+
+// fetchMore requests the next page from the source, the page is read on
+// another goroutine and appended once it's posted back to the UI goroutine.
+func (t *Table) fetchMore() {
+	if t.source == nil || t.fetching || t.stopped || t.fetchErr != nil || t.source.Done() {
+		return
+	}
+
+	source := t.source
+	if t.post == nil {
+		rows, err := source.NextRows(tablePageSize)
+		t.appendPage(source, rows, err)
+		return
+	}
+
+	t.fetching = true
+	go func() {
+		rows, err := source.NextRows(tablePageSize)
+		t.post(func() {
+			t.appendPage(source, rows, err)
+		})
+	}()
+}
+
+func (t *Table) appendPage(source RowSource, rows []shared.Row, err error) {
+	if source != t.source {
+		return
+	}
+
+	t.fetching = false
+	t.fetchErr = err
+	if t.stopped {
+		return
+	}
+
+	page := make([][][]rune, 0, len(rows))
+	for _, row := range rows {
+		cells := make([][]rune, row.Len())
+		for i := range cells {
+			cells[i] = []rune(row.Text(i))
 		}
+		page = append(page, cells)
+	}
+
+	t.rows = append(t.rows, rows...)
+	t.data = append(t.data, page...)
+	t.growColWidths(page)
+}
+
+// cellStyle picks the style for a cell, NULLs are italic in their own colour
+// so they can't be mistaken for the text 'NULL', a darker one on the light
+// row backgrounds. Number cells report true so they can be right aligned.
+func (t *Table) cellStyle(row, col int, style tcell.Style) (tcell.Style, bool) {
+	if row == 0 || row > len(t.rows) || col >= t.rows[row-1].Len() {
+		return style, false
+	}
+
+	value := t.rows[row-1]
+	if value.IsNull(col) {
+		_, bg, _ := style.Decompose()
+		if bg == tcell.ColorGray || bg == tcell.ColorWhite {
+			return t.nullLightStyle.Background(bg), false
+		}
+		return t.nullStyle.Background(bg), false
+	}
+
+	return style, value.IsNumber(col)
+}
+
+// StopFetching closes the source so no more rows are read, the rows that
+// were already fetched stay in the table.
+func (t *Table) StopFetching() {
+	if t.source == nil || t.stopped || (!t.fetching && t.source.Done()) {
+		return
+	}
+
+	t.stopped = true
+	go t.source.Close()
+}
+
+// Rows returns the rows fetched so far, done is false when the source has
+// rows that haven't been read yet.
+func (t *Table) Rows() (rows []shared.Row, done bool) {
+	if t.source == nil {
+		return nil, true
+	}
+
+	return t.rows, !t.stopped && t.fetchErr == nil && t.source.Done()
+}
+
+// Source is the source of the rows being shown, nil when the table is
+// showing a message.
+func (t *Table) Source() RowSource {
+	return t.source
+}
+
+// SelectedCell returns the index into the fetched rows and the column of the
+// selected cell, ok is false when no row is selected.
+func (t *Table) SelectedCell() (row, col int, ok bool) {
+	row = t.sRow + t.anchorRow - 1
+	if t.sRow < 0 || t.sCol < 0 || row < 0 || row >= len(t.rows) {
+		return 0, 0, false
+	}
+
+	return row, t.sCol, true
+}
+
+// SetRow replaces one of the fetched rows, used once a change to it has
+// been written back.
+func (t *Table) SetRow(row int, value shared.Row) {
+	if row < 0 || row >= len(t.rows) {
+		return
+	}
+
+	cells := make([][]rune, value.Len())
+	for i := range cells {
+		cells[i] = []rune(value.Text(i))
 	}
+
+	t.rows[row] = value
+	t.data[row+1] = cells
+	t.growColWidths(t.data[row+1 : row+2])
+}
+
+func (t *Table) SetPostFunc(post PostFunc) {
+	t.post = post
 }
 
 func (t *Table) HandleInput(ev *tcell.EventKey) {
@@ -133,6 +309,10 @@ EventLoop:
 			break
 		}
 
+		if t.sRow+t.anchorRow >= len(t.data)-1-t.tableHeight {
+			t.fetchMore()
+		}
+
 		if t.sRow+t.anchorRow >= len(t.data)-1 || t.expanded {
 			break EventLoop
 		}
@@ -170,6 +350,67 @@ EventLoop:
 		t.expanded = true
 	case tcell.KeyEsc:
 		t.expanded = false
+	case tcell.KeyRune:
+		switch {
+		case t.expanded:
+		case ev.Rune() == 's':
+			t.StopFetching()
+		case ev.Rune() == ' ':
+			t.toggleMark()
+		}
+	}
+}
+
+// toggleMark marks or unmarks the selected row.
+func (t *Table) toggleMark() {
+	row, _, ok := t.SelectedCell()
+	if !ok {
+		return
+	}
+
+	if t.marked[row] {
+		delete(t.marked, row)
+	} else {
+		t.marked[row] = true
+	}
+}
+
+// MarkedRows returns the indexes into the fetched rows of the marked rows in
+// order.
+func (t *Table) MarkedRows() []int {
+	var rows []int
+	for k := range t.marked {
+		rows = append(rows, k)
+	}
+	slices.Sort(rows)
+
+	return rows
+}
+
+// RemoveRows takes rows out of the fetched rows once they've been deleted,
+// the marks are cleared.
+func (t *Table) RemoveRows(rows []int) {
+	remove := make(map[int]bool)
+	for _, v := range rows {
+		remove[v] = true
+	}
+
+	var kept []shared.Row
+	data := t.data[:1]
+	for i, v := range t.rows {
+		if !remove[i] {
+			kept = append(kept, v)
+			data = append(data, t.data[i+1])
+		}
+	}
+
+	t.rows = kept
+	t.data = data
+	t.marked = make(map[int]bool)
+
+	if t.sRow+t.anchorRow > len(t.rows) {
+		t.anchorRow = max(len(t.rows)-t.tableHeight+1, 0)
+		t.sRow = len(t.rows) - t.anchorRow
 	}
 }
 
@@ -179,6 +420,8 @@ func (t *Table) Render(screen tcell.Screen) {
 		return
 	}
 
+	t.renderRowCount(screen)
+
 	lastAnchorCol, width, finalCol := 0, 0, false
 	colSepLines := make([]int, len(t.data[0]))
 
@@ -209,11 +452,32 @@ func (t *Table) Render(screen tcell.Screen) {
 			style := t.evenRowStyle
 			if j == t.sRow && i == t.sCol {
 				style = t.hlStyle
+			} else if t.marked[j+t.anchorRow-1] {
+				style = t.markStyle
 			} else if (j+t.anchorRow)%2 == 1 {
 				style = t.oddRowStyle
 			}
 
-			for u, ch := range visibleRows[j][i] {
+			style, rightAlign := t.cellStyle(j+t.anchorRow, i, style)
+			cell := visibleRows[j][i]
+			cellLen := len(cell)
+			pad := 0
+			if rightAlign && cellLen < t.colWidths[i] {
+				pad = t.colWidths[i] - cellLen
+			}
+
+			for k := range pad {
+				if rowLeft+k < t.left {
+					continue
+				} else if rowLeft+k >= t.right {
+					break
+				}
+
+				screen.SetContent(rowLeft+k, t.top+j+1, ' ', nil, style)
+			}
+
+			for u, ch := range cell {
+				u += pad
 				if rowLeft+u < t.left {
 					continue
 				}
@@ -230,15 +494,14 @@ func (t *Table) Render(screen tcell.Screen) {
 				}
 			}
 
-			cellLen := len(visibleRows[j][i])
-			for k := range t.colWidths[i] - cellLen {
-				if rowLeft+cellLen+k < t.left {
+			for k := range t.colWidths[i] - cellLen - pad {
+				if rowLeft+pad+cellLen+k < t.left {
 					continue
-				} else if rowLeft+cellLen+k >= t.right {
+				} else if rowLeft+pad+cellLen+k >= t.right {
 					break
 				}
 
-				screen.SetContent(rowLeft+cellLen+k, t.top+j+1, ' ', nil, style)
+				screen.SetContent(rowLeft+pad+cellLen+k, t.top+j+1, ' ', nil, style)
 			}
 		}
 
@@ -299,6 +562,61 @@ func (t *Table) Render(screen tcell.Screen) {
 	}
 }
 
+// renderRowCount writes how many rows have been fetched into the bottom
+// border of the table.
+func (t *Table) renderRowCount(screen tcell.Screen) {
+	if t.source == nil {
+		return
+	}
+
+	var msg string
+	rows := len(t.data) - 1
+	switch {
+	case t.fetchErr != nil:
+		msg = fmt.Sprintf(" %d rows fetched, error: %s ", rows, t.fetchErr.Error())
+	case t.stopped:
+		msg = fmt.Sprintf(" %d rows fetched, stopped ", rows)
+	case t.fetching:
+		msg = fmt.Sprintf(" %d rows fetched, fetching... ", rows)
+	case t.source.Done() && truncated(t.source):
+		msg = fmt.Sprintf(" %d rows fetched, the rest were dropped when the transaction ran another statement ", rows)
+	case t.source.Done() && hasNextSet(t.source):
+		msg = fmt.Sprintf(" %d rows, more result sets (> - Next Result Set) ", rows)
+	case t.source.Done():
+		msg = fmt.Sprintf(" %d rows ", rows)
+	default:
+		msg = fmt.Sprintf(" %d rows fetched, more available (s - Stop Fetching) ", rows)
+	}
+
+	if len(t.marked) > 0 {
+		msg = strings.TrimSuffix(msg, " ") + fmt.Sprintf(", %d marked ", len(t.marked))
+	}
+
+	for i, ch := range msg {
+		if t.left+2+i >= t.right-1 {
+			break
+		}
+
+		screen.SetContent(t.left+2+i, t.bottom, ch, nil, *t.style)
+	}
+}
+
+// setsSource is a RowSource that can be followed by another result set from
+// the same batch.
+type setsSource interface {
+	HasNextSet() bool
+}
+
+func truncated(source RowSource) bool {
+	ts, ok := source.(truncatedSource)
+	return ok && ts.Truncated()
+}
+
+func hasNextSet(source RowSource) bool {
+	ss, ok := source.(setsSource)
+	return ok && ss.HasNextSet()
+}
+
 func (t *Table) lastColSelected() bool {
 	if len(t.data) == 0 {
 		return false
@@ -328,12 +646,23 @@ func (t *Table) lastRowVisible() bool {
 }
 
 func (t *Table) TableFunc() TableDataFunc {
-	return func(table [][][]rune, resultMsg []rune) {
-		if table == nil && resultMsg == nil {
+	return func(source RowSource, resultMsg []rune) {
+		if source == nil && resultMsg == nil {
 			return
 		}
 
-		if resultMsg != nil && table == nil {
+		if t.source != nil {
+			t.StopFetching()
+		}
+
+		var table [][][]rune
+		if source != nil {
+			var header [][]rune
+			for _, v := range source.ColumnNames() {
+				header = append(header, []rune(v))
+			}
+			table = [][][]rune{header}
+		} else {
 			table = [][][]rune{
 				[][]rune{
 					[]rune("Results"),
@@ -343,7 +672,13 @@ func (t *Table) TableFunc() TableDataFunc {
 		}
 
 		t.data = table
+		t.rows = nil
+		t.marked = make(map[int]bool)
 		t.resultMsg = resultMsg
+		t.source = source
+		t.fetching = false
+		t.stopped = false
+		t.fetchErr = nil
 
 		t.refresh = true
 		t.sCol = -1
@@ -352,5 +687,6 @@ func (t *Table) TableFunc() TableDataFunc {
 		t.anchorCol = 0
 		t.lastAnchorCol = 0
 		t.CalculateColWidths()
+		t.fetchMore()
 	}
 }
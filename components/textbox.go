@@ -15,6 +15,7 @@ type TextBox struct {
 	hlStyle          tcell.Style
 	buf              []rune
 	label            []rune
+	placeholder      []rune
 	focus            bool
 }
 
@@ -117,6 +118,8 @@ func (tbox *TextBox) Render(screen tcell.Screen) {
 
 		if i+1 < len(tbox.buf)+2 {
 			screen.SetContent(tbox.left+i, tbox.top+2, tbox.buf[i-1], nil, *tbox.style)
+		} else if len(tbox.buf) == 0 && i-1 < len(tbox.placeholder) {
+			screen.SetContent(tbox.left+i, tbox.top+2, tbox.placeholder[i-1], nil, tbox.style.Foreground(tcell.ColorDarkGray).Italic(true))
 		} else {
 			screen.SetContent(tbox.left+i, tbox.top+2, ' ', nil, *tbox.style)
 		}
@@ -139,6 +142,22 @@ func (tbox *TextBox) GetString() string {
 	return string(tbox.buf)
 }
 
+// SetString replaces the contents with s and moves the cursor to the end.
+func (tbox *TextBox) SetString(s string) {
+	tbox.buf = []rune(s)
+	tbox.cursorPos = min(len(tbox.buf), tbox.right-tbox.left)
+	tbox.offset = len(tbox.buf) - tbox.cursorPos
+}
+
+func (tbox *TextBox) SetLabel(label []rune) {
+	tbox.label = label
+}
+
+// SetPlaceholder sets the text shown dimmed while the box is empty.
+func (tbox *TextBox) SetPlaceholder(placeholder []rune) {
+	tbox.placeholder = placeholder
+}
+
 func (tbox *TextBox) Reset() {
 	tbox.buf = []rune{}
 	tbox.cursorPos = 0
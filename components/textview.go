@@ -0,0 +1,75 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TextView is a read only popup for showing multi-line text like SQL
+// definitions, long lines are wrapped and Up/Down scroll through the text.
+type TextView struct {
+	window *Window
+	style  *tcell.Style
+	lines  [][]rune
+	scroll int
+}
+
+func CreateTextView(left, top, right, bottom int, style *tcell.Style) *TextView {
+	return &TextView{
+		window: CreateWindow(left, top, right, bottom, 0, 0, true, true, nil, style),
+		style:  style,
+	}
+}
+
+func (tv *TextView) SetText(title, text string) {
+	tv.window.SetTitle([]rune(title))
+	tv.scroll = 0
+	tv.lines = nil
+
+	left, _, right, _ := tv.window.GetUsableDimensions()
+	width := right - left
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		runes := []rune(strings.TrimRight(line, "\r"))
+		for width > 0 && len(runes) > width {
+			tv.lines = append(tv.lines, runes[:width])
+			runes = runes[width:]
+		}
+		tv.lines = append(tv.lines, runes)
+	}
+}
+
+func (tv *TextView) HandleInput(ev *tcell.EventKey) {
+	_, top, _, bottom := tv.window.GetUsableDimensions()
+	height := bottom - top
+
+	switch ev.Key() {
+	case tcell.KeyUp:
+		if tv.scroll > 0 {
+			tv.scroll--
+		}
+	case tcell.KeyDown:
+		if tv.scroll < len(tv.lines)-height {
+			tv.scroll++
+		}
+	case tcell.KeyPgUp:
+		tv.scroll = max(tv.scroll-height, 0)
+	case tcell.KeyPgDn:
+		tv.scroll = max(min(tv.scroll+height, len(tv.lines)-height), 0)
+	}
+}
+
+func (tv *TextView) Render(screen tcell.Screen) {
+	tv.window.Render(screen)
+	left, top, right, bottom := tv.window.GetUsableDimensions()
+
+	for i := tv.scroll; i < len(tv.lines) && top+i-tv.scroll < bottom; i++ {
+		for j, ch := range tv.lines[i] {
+			if left+j >= right {
+				break
+			}
+
+			screen.SetContent(left+j, top+i-tv.scroll, ch, nil, *tv.style)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package components
 
 import (
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -9,6 +11,8 @@ var (
 	SecondSpacing = []rune("  ")
 )
 
+// TreeItem is a node in a Tree, Style overrides the tree's style for the
+// item when it's set.
 type TreeItem struct {
 	expanded bool
 	Label    []rune
@@ -16,6 +20,11 @@ type TreeItem struct {
 	Value    string
 	Child    bool
 	Level    int
+	Style    *tcell.Style
+}
+
+func (item *TreeItem) SetExpanded(expanded bool) {
+	item.expanded = expanded
 }
 
 type Tree struct {
@@ -66,10 +75,28 @@ func (tree *Tree) Resize(left, top, right, bottom int) {
 
 func (tree *Tree) SetItems(items []*TreeItem) {
 	tree.treeItems = items
+	tree.visibleItems = nil
+}
+
+func (tree *Tree) SetLabel(label []rune) {
+	tree.label = label
+}
+
+// Reset moves the selection back to the first item, used when the items are
+// replaced with something unrelated.
+func (tree *Tree) Reset() {
+	tree.selected = 0
+	tree.offset = 0
 }
 
+// SelectedItem returns the highlighted item, nested items are only known
+// once the tree has been rendered so the top level is used before that.
 func (tree *Tree) SelectedItem() *TreeItem {
-	if len(tree.treeItems) > 0 {
+	if tree.selected >= 0 && tree.selected < len(tree.visibleItems) {
+		return tree.visibleItems[tree.selected]
+	}
+
+	if tree.selected >= 0 && tree.selected < len(tree.treeItems) {
 		return tree.treeItems[tree.selected]
 	}
 
@@ -91,7 +118,7 @@ func (tree *Tree) HandleInput(ev *tcell.EventKey) {
 			tree.offset--
 		}
 	case tcell.KeyDown:
-		if tree.selected == len(tree.visibleItems)-1 {
+		if tree.selected >= len(tree.visibleItems)-1 {
 			break
 		}
 		tree.selected++
@@ -100,6 +127,10 @@ func (tree *Tree) HandleInput(ev *tcell.EventKey) {
 			tree.offset++
 		}
 	case tcell.KeyEnter:
+		if tree.selected < 0 || tree.selected >= len(tree.visibleItems) {
+			break
+		}
+
 		if len(tree.visibleItems[tree.selected].Children) > 0 {
 			tree.visibleItems[tree.selected].expanded = !tree.visibleItems[tree.selected].expanded
 		}
@@ -109,18 +140,7 @@ func (tree *Tree) HandleInput(ev *tcell.EventKey) {
 func (tree *Tree) Render(screen tcell.Screen) {
 	tree.visibleItems = []*TreeItem{}
 	for _, v := range tree.treeItems {
-		tree.visibleItems = append(tree.visibleItems, v)
-
-		if len(v.Children) <= 0 || !v.expanded {
-			continue
-		}
-
-		for _, v := range v.Children {
-			tree.visibleItems = append(tree.visibleItems, v)
-			if len(v.Children) > 0 && v.expanded {
-				tree.visibleItems = append(tree.visibleItems, v.Children...)
-			}
-		}
+		tree.addVisible(v)
 
 		if len(tree.visibleItems) >= tree.bottom-tree.top+tree.offset {
 			break
@@ -184,16 +204,22 @@ func (tree *Tree) Render(screen tcell.Screen) {
 		}
 
 		var label []rune
-		if tree.visibleItems[i].Level == 1 {
+		switch level := tree.visibleItems[i].Level; {
+		case level == 1:
 			label = append(label, FirstSpacing...)
-		} else if tree.visibleItems[i].Level == 2 {
+		case level == 2:
 			label = append(label, SecondSpacing...)
+		case level > 2:
+			label = append(label, []rune(strings.Repeat(" ", level))...)
 		}
 
 		label = append(label, ch)
 		label = append(label, tree.visibleItems[i].Label...)
 
 		style := tree.style
+		if tree.visibleItems[i].Style != nil {
+			style = tree.visibleItems[i].Style
+		}
 		if tree.selected == i {
 			style = &tree.hlStyle
 		}
@@ -206,3 +232,16 @@ func (tree *Tree) Render(screen tcell.Screen) {
 		}
 	}
 }
+
+// addVisible adds item and, if it's expanded, its children to the visible
+// items.
+func (tree *Tree) addVisible(item *TreeItem) {
+	tree.visibleItems = append(tree.visibleItems, item)
+	if !item.expanded {
+		return
+	}
+
+	for _, v := range item.Children {
+		tree.addVisible(v)
+	}
+}
@@ -51,6 +51,10 @@ func (window *Window) RequestRows(n int) (left, top, right, bottom int) {
 	return -1, -1, -1, -1
 }
 
+func (window *Window) SetTitle(title []rune) {
+	window.title = title
+}
+
 func (window *Window) Resize(left, top, right, bottom int) {
 	window.left = left
 	window.top = top
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrBrowseFilter = errors.New("the filter has to be a single condition")
+
+// Browse is a page of a table's rows, filtered by a WHERE condition and
+// sorted by a column. Without a sort column the rows are ordered by the
+// primary key so pages don't overlap.
+type Browse struct {
+	Table    Table
+	Filter   string
+	Sort     string
+	Desc     bool
+	Page     int
+	PageSize int
+}
+
+// SortBy sorts by col, sorting by the same column again reverses the order.
+// It goes back to the first page.
+func (b *Browse) SortBy(col string) {
+	if b.Sort == col {
+		b.Desc = !b.Desc
+	} else {
+		b.Sort = col
+		b.Desc = false
+	}
+
+	b.Page = 0
+}
+
+// SetFilter replaces the WHERE condition, an empty one shows every row. It
+// goes back to the first page.
+func (b *Browse) SetFilter(filter string) {
+	b.Filter = strings.TrimSpace(filter)
+	b.Page = 0
+}
+
+// Query builds the SELECT for the current page, SQL Server pages with
+// OFFSET FETCH and everything else with LIMIT and OFFSET.
+func (b *Browse) Query(driver string) string {
+	m := &migration{driver: driver}
+
+	query := "SELECT * FROM " + m.table(b.Table.Name)
+	if b.Filter != "" {
+		query += " WHERE (" + b.Filter + ")"
+	}
+
+	var order []string
+	if b.Sort != "" {
+		col := m.quote(b.Sort)
+		if b.Desc {
+			col += " DESC"
+		}
+		order = append(order, col)
+	}
+	for _, v := range b.Table.Columns {
+		if v.PrimaryKey && v.Name != b.Sort {
+			order = append(order, m.quote(v.Name))
+		}
+	}
+
+	switch {
+	case len(order) > 0:
+		query += " ORDER BY " + strings.Join(order, ", ")
+	case driver == "sqlserver":
+		query += " ORDER BY (SELECT NULL)"
+	}
+
+	if driver == "sqlserver" {
+		return query + fmt.Sprintf(" OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", b.Page*b.PageSize, b.PageSize)
+	}
+
+	return query + fmt.Sprintf(" LIMIT %d OFFSET %d", b.PageSize, b.Page*b.PageSize)
+}
+
+// Select runs the query for the current page on database. The filter is
+// refused if it ends the statement or closes the parentheses it's put in,
+// so it can't run anything but the one SELECT.
+func (b *Browse) Select(ctx context.Context, database Database) (*ResultSet, error) {
+	driver, _ := database.Info()
+
+	depth := 0
+	for _, v := range Tokenize(driver, b.Filter) {
+		switch {
+		case v.isPunct(";"):
+			return nil, ErrBrowseFilter
+		case v.isPunct("("):
+			depth++
+		case v.isPunct(")"):
+			depth--
+		}
+		if depth < 0 {
+			return nil, ErrBrowseFilter
+		}
+	}
+	if depth != 0 {
+		return nil, ErrBrowseFilter
+	}
+
+	query := b.Query(driver)
+	rs, err := database.Select(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.statement = query
+	return rs, nil
+}
@@ -1,13 +1,10 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"errors"
-	"regexp"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
-	"github.com/sleepy-day/sqline/components"
 )
 
 var (
@@ -19,12 +16,34 @@ type Database interface {
 	GetDatabases() ([]DbInfo, error)
 	GetSchemas() ([]SchemaInfo, error)
 	GetTables() ([]Table, error)
+	GetTriggers() ([]Trigger, error)
 	GetRoles() ([]RoleInfo, error)
-	GetExecSQLFunc() components.ExecSQLFunc
-	Select(cmd string) ([][][]rune, error)
-	Exec(cmd string) ([]rune, error)
+	GetExecSQLFunc() ExecSQLFunc
+	SetContinueOnError(bool)
+	SetHistoryFunc(HistoryFunc)
+	Begin() error
+	Commit() error
+	Rollback() error
+	SetAutocommit(bool)
+	Transaction() TxStatus
+	Select(ctx context.Context, cmd string, args ...any) (*ResultSet, error)
+	Exec(ctx context.Context, cmd string, args ...any) (int64, error)
+	Close() error
 }
 
+// ExecSQLFunc runs the statements in cmd, ctx is passed down to every query
+// so cancelling it stops the statement that's currently running. binds is
+// nil unless the script's placeholders have been filled in.
+type ExecSQLFunc func(ctx context.Context, cmd []rune, binds Binds) error
+
+// HistoryFunc is given the results of every script run through an
+// ExecSQLFunc, it's called on the goroutine that ran the script.
+type HistoryFunc func(results []StatementResult)
+
+// rowsAffectedFormat is the message shown for a statement run through
+// RunScript that doesn't return rows.
+const rowsAffectedFormat = "%d rows affected"
+
 type DbInfo struct {
 	Name  string `db:"Name"`
 	Owner string `db:"Owner"`
@@ -53,42 +72,106 @@ type Column struct {
 	NotNull      bool
 	DefaultValue *string
 	PrimaryKey   bool
-	FKTo         *string
-	OnUpdate     *string
-	OnDelete     *string
 }
 
+type ObjectType byte
+
+const (
+	TableObject ObjectType = iota
+	ViewObject
+	VirtualTableObject
+)
+
+func (objType ObjectType) String() string {
+	switch objType {
+	case ViewObject:
+		return "View"
+	case VirtualTableObject:
+		return "Virtual Table"
+	}
+
+	return "Table"
+}
+
+// Table is a table, view or virtual table along with its columns, indexes
+// and triggers. Definition is the SQL the object was created with when the
+// database keeps it.
 type Table struct {
-	Name    string
-	Columns []Column
-	Indexes []Index
+	Name        string
+	Type        ObjectType
+	Definition  string
+	Columns     []Column
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+	Triggers    []Trigger
+}
+
+// ForeignKey is a foreign key constraint on a table, Columns and RefColumns
+// pair up in order so composite keys keep their columns together. ID is the
+// constraint name, Sqlite doesn't keep those so its id from
+// pragma_foreign_key_list is used instead.
+type ForeignKey struct {
+	ID         string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+}
+
+// ForeignKeyData is one column of a foreign key, Seq is its position in the
+// key.
+type ForeignKeyData struct {
+	TableName  string  `db:"TableName"`
+	ID         string  `db:"ID"`
+	Seq        int     `db:"Seq"`
+	ColumnName string  `db:"ColumnName"`
+	RefTable   string  `db:"RefTable"`
+	RefColumn  *string `db:"RefColumn"`
+	OnUpdate   string  `db:"OnUpdate"`
+	OnDelete   string  `db:"OnDelete"`
+}
+
+type Trigger struct {
+	Name       string `db:"Name"`
+	TableName  string `db:"TableName"`
+	Definition string `db:"Definition"`
+}
+
+// ObjectDefinition is the SQL for a table or view by name, used to fill in
+// Table.Definition.
+type ObjectDefinition struct {
+	Name       string  `db:"Name"`
+	Definition *string `db:"Definition"`
 }
 
 type TableData struct {
 	TableName    string  `db:"TableName"`
+	TableType    string  `db:"TableType"`
 	ColumnName   string  `db:"ColumnName"`
 	Type         string  `db:"Type"`
 	NotNull      bool    `db:"NotNull"`
 	DefaultValue *string `db:"DefaultValue"`
 	PrimaryKey   bool    `db:"PrimaryKey"`
-	FKTo         *string `db:"FKTo"`
-	OnUpdate     *string `db:"OnUpdate"`
-	OnDelete     *string `db:"OnDelete"`
 }
 
 type IndexData struct {
-	TableName  string `db:"TableName"`
-	IndexName  string `db:"IndexName"`
-	Unique     bool   `db:"Unique"`
-	Partial    bool   `db:"Partial"`
-	SeqNo      int    `db:"SeqNo"`
-	ColumnName string `db:"ColumnName"`
+	TableName  string  `db:"TableName"`
+	IndexName  string  `db:"IndexName"`
+	Unique     bool    `db:"Unique"`
+	Partial    bool    `db:"Partial"`
+	SeqNo      int     `db:"SeqNo"`
+	ColumnName string  `db:"ColumnName"`
+	Definition *string `db:"Definition"`
 }
 
+// Index is an index on a table, Definition is the SQL that creates it when
+// the database keeps it or it can be rebuilt.
 type Index struct {
-	TableName string
-	Name      string
-	Cols      []IndexCol
+	TableName  string
+	Name       string
+	Definition string
+	Cols       []IndexCol
 }
 
 type IndexCol struct {
@@ -99,7 +182,7 @@ type IndexCol struct {
 }
 
 type RoleInfo struct {
-	Name string
+	Name string `db:"Name"`
 }
 
 func TestConnection(driver, connStr string) error {
@@ -107,48 +190,202 @@ func TestConnection(driver, connStr string) error {
 	return err
 }
 
-func convertRowsToRuneArr(rows *sql.Rows) ([][][]rune, error) {
-	headers, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
+func createExecSQLFunc(database Database, tableFunc ResultFunc, updateViewFunc UpdateViewFunc, continueOnError *bool, historyFunc *HistoryFunc) ExecSQLFunc {
+	return func(ctx context.Context, cmd []rune, binds Binds) error {
+		if len(cmd) == 0 {
+			return nil
+		}
+
+		results := RunScript(ctx, database, string(cmd), *continueOnError, binds)
+		if len(results) == 0 {
+			return nil
+		}
 
-	table := [][][]rune{}
-	headerArr := [][]rune{}
+		if *historyFunc != nil {
+			(*historyFunc)(results)
+		}
 
-	for _, v := range headers {
-		headerArr = append(headerArr, []rune(v))
+		for _, v := range results {
+			if v.Kind == DDL && v.Err == nil {
+				tables, err := database.GetTables()
+				if err == nil {
+					updateViewFunc(tables)
+				}
+				break
+			}
+		}
+
+		if len(results) == 1 {
+			if results[0].Err != nil {
+				return results[0].Err
+			}
+
+			tableFunc(results[0].Rows, results[0].Result)
+			return nil
+		}
+
+		tableFunc(scriptSummary(results), nil)
+		for _, v := range results {
+			if v.Err != nil {
+				return v.Err
+			}
+		}
+
+		return nil
 	}
-	table = append(table, headerArr)
+}
+
+func groupTableData(tableData []TableData, indexData []IndexData) []Table {
+	var tables []Table
+	tableMap := make(map[string]int)
+	for _, v := range tableData {
+		col := Column{
+			Name:         v.ColumnName,
+			Type:         v.Type,
+			NotNull:      v.NotNull,
+			DefaultValue: v.DefaultValue,
+			PrimaryKey:   v.PrimaryKey,
+		}
 
-	for rows.Next() {
-		row := make([]interface{}, len(headers))
-		for i := range headers {
-			row[i] = new(sql.Null[sql.RawBytes])
+		i, ok := tableMap[v.TableName]
+		if !ok {
+			tableMap[v.TableName] = len(tables)
+			tables = append(tables, Table{
+				Name:    v.TableName,
+				Type:    objectType(v.TableType),
+				Columns: []Column{col},
+			})
+			continue
 		}
 
-		err := rows.Scan(row...)
-		if err != nil {
-			panic(err)
+		tables[i].Columns = append(tables[i].Columns, col)
+	}
+
+	var indexes []Index
+	indexMap := make(map[string]int)
+	for _, v := range indexData {
+		indexCol := IndexCol{
+			ColumnName: v.ColumnName,
+			Unique:     v.Unique,
+			Partial:    v.Partial,
+			SeqNo:      v.SeqNo,
 		}
 
-		rowRunes := [][]rune{}
-		for _, cell := range row {
-			if cell.(*sql.Null[sql.RawBytes]).Valid {
-				value, _ := cell.(*sql.Null[sql.RawBytes]).Value()
-				rowRunes = append(rowRunes, []rune(string(value.(sql.RawBytes))))
-			} else {
-				rowRunes = append(rowRunes, []rune("NULL"))
+		key := v.TableName + "." + v.IndexName
+		i, ok := indexMap[key]
+		if !ok {
+			indexMap[key] = len(indexes)
+			index := Index{
+				Name:      v.IndexName,
+				TableName: v.TableName,
+				Cols:      []IndexCol{indexCol},
+			}
+			if v.Definition != nil {
+				index.Definition = *v.Definition
 			}
+
+			indexes = append(indexes, index)
+			continue
+		}
+
+		indexes[i].Cols = append(indexes[i].Cols, indexCol)
+	}
+
+	for _, v := range indexes {
+		i, ok := tableMap[v.TableName]
+		if ok {
+			tables[i].Indexes = append(tables[i].Indexes, v)
+		}
+	}
+
+	return tables
+}
+
+func objectType(tableType string) ObjectType {
+	switch tableType {
+	case "VIEW":
+		return ViewObject
+	case "VIRTUAL TABLE":
+		return VirtualTableObject
+	}
+
+	return TableObject
+}
+
+func setDefinitions(tables []Table, defs []ObjectDefinition) {
+	defMap := make(map[string]string)
+	for _, v := range defs {
+		if v.Definition != nil {
+			defMap[v.Name] = *v.Definition
+		}
+	}
+
+	for i := range tables {
+		tables[i].Definition = defMap[tables[i].Name]
+	}
+}
+
+func attachTriggers(tables []Table, triggers []Trigger) {
+	tableMap := make(map[string]int)
+	for i, v := range tables {
+		tableMap[v.Name] = i
+	}
+
+	for _, v := range triggers {
+		if i, ok := tableMap[v.TableName]; ok {
+			tables[i].Triggers = append(tables[i].Triggers, v)
 		}
+	}
+}
 
-		table = append(table, rowRunes)
+// attachForeignKeys groups the rows of fkData into a ForeignKey per
+// constraint and adds them to their tables, fkData has to be ordered by
+// table, constraint and then Seq.
+func attachForeignKeys(tables []Table, fkData []ForeignKeyData) {
+	tableMap := make(map[string]int)
+	for i, v := range tables {
+		tableMap[v.Name] = i
 	}
 
-	return table, nil
+	var fk *ForeignKey
+	last := -1
+	for _, v := range fkData {
+		i, ok := tableMap[v.TableName]
+		if !ok {
+			continue
+		}
+
+		if fk == nil || i != last || fk.ID != v.ID {
+			tables[i].ForeignKeys = append(tables[i].ForeignKeys, ForeignKey{
+				ID:       v.ID,
+				RefTable: v.RefTable,
+				OnUpdate: v.OnUpdate,
+				OnDelete: v.OnDelete,
+			})
+			fk = &tables[i].ForeignKeys[len(tables[i].ForeignKeys)-1]
+			last = i
+		}
+
+		refColumn := ""
+		if v.RefColumn != nil {
+			refColumn = *v.RefColumn
+		}
+
+		fk.Columns = append(fk.Columns, v.ColumnName)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
 }
 
-func selectRegex() *regexp.Regexp {
-	regex := regexp.MustCompile(`(?i)^select\s`)
-	return regex
+// ColumnForeignKey returns the table and column that column references, ok is
+// false if it isn't part of a foreign key.
+func (table Table) ColumnForeignKey(column string) (refTable, refColumn string, ok bool) {
+	for _, fk := range table.ForeignKeys {
+		for i, v := range fk.Columns {
+			if v == column {
+				return fk.RefTable, fk.RefColumns[i], true
+			}
+		}
+	}
+
+	return "", "", false
 }
@@ -0,0 +1,809 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// ChangeKind is how an object differs between two schemas.
+type ChangeKind byte
+
+const (
+	Added ChangeKind = iota
+	Removed
+	Changed
+)
+
+func (kind ChangeKind) String() string {
+	switch kind {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+
+	return "~"
+}
+
+// SchemaDiff is the difference between two schemas, the changes are what's
+// needed to turn the From schema into the To schema. Only tables are
+// compared, views, virtual tables and triggers are left out.
+type SchemaDiff struct {
+	Tables []TableDiff
+	// names holds every object name in either schema, lower cased, so the
+	// tables Sqlite rebuilds through can be given a name that's free.
+	names map[string]bool
+}
+
+// TableDiff is a table that's been added, removed or changed. Added and
+// removed tables list every column, index and foreign key as added or
+// removed along with them.
+type TableDiff struct {
+	Name        string
+	Kind        ChangeKind
+	From, To    *Table
+	Columns     []ColumnDiff
+	Indexes     []IndexDiff
+	ForeignKeys []ForeignKeyDiff
+}
+
+// ColumnDiff is a column that's been added, removed or changed, Changes
+// describes each attribute of a changed column that's different.
+type ColumnDiff struct {
+	Name     string
+	Kind     ChangeKind
+	From, To *Column
+	Changes  []string
+}
+
+type IndexDiff struct {
+	Name     string
+	Kind     ChangeKind
+	From, To *Index
+}
+
+// ForeignKeyDiff is a foreign key that's been added, removed or changed,
+// foreign keys are matched by their columns and referenced table since
+// Sqlite doesn't name them.
+type ForeignKeyDiff struct {
+	Kind     ChangeKind
+	From, To *ForeignKey
+}
+
+// DiffSchemas compares the tables in from and to, tables are matched by
+// name and the result is sorted by table name.
+func DiffSchemas(from, to []Table) SchemaDiff {
+	fromTables := baseTables(from)
+	toTables := baseTables(to)
+
+	var names []string
+	for name := range fromTables {
+		names = append(names, name)
+	}
+	for name := range toTables {
+		if _, ok := fromTables[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	diff := SchemaDiff{names: make(map[string]bool)}
+	for _, v := range slices.Concat(from, to) {
+		diff.names[strings.ToLower(v.Name)] = true
+	}
+
+	for _, name := range names {
+		fromTable, toTable := fromTables[name], toTables[name]
+
+		table := TableDiff{
+			Name: name,
+			Kind: Changed,
+			From: fromTable,
+			To:   toTable,
+		}
+
+		switch {
+		case fromTable == nil:
+			table.Kind = Added
+			fromTable = &Table{}
+		case toTable == nil:
+			table.Kind = Removed
+			toTable = &Table{}
+		}
+
+		table.Columns = diffColumns(fromTable, toTable)
+		table.Indexes = diffIndexes(fromTable, toTable)
+		table.ForeignKeys = diffForeignKeys(fromTable, toTable)
+
+		if table.Kind != Changed || len(table.Columns) > 0 || len(table.Indexes) > 0 || len(table.ForeignKeys) > 0 {
+			diff.Tables = append(diff.Tables, table)
+		}
+	}
+
+	return diff
+}
+
+func (diff SchemaDiff) Empty() bool {
+	return len(diff.Tables) == 0
+}
+
+// Summary is the text shown for the table in the diff tree.
+func (table TableDiff) Summary() string {
+	return fmt.Sprintf("%s table %s", table.Kind, table.Name)
+}
+
+func (col ColumnDiff) Summary() string {
+	switch col.Kind {
+	case Added:
+		return fmt.Sprintf("+ column %s %s", col.Name, col.To.Type)
+	case Removed:
+		return fmt.Sprintf("- column %s %s", col.Name, col.From.Type)
+	}
+
+	return fmt.Sprintf("~ column %s: %s", col.Name, strings.Join(col.Changes, ", "))
+}
+
+func (index IndexDiff) Summary() string {
+	switch index.Kind {
+	case Added:
+		return fmt.Sprintf("+ index %s %s", index.Name, indexText(*index.To))
+	case Removed:
+		return fmt.Sprintf("- index %s %s", index.Name, indexText(*index.From))
+	}
+
+	return fmt.Sprintf("~ index %s: %s → %s", index.Name, indexText(*index.From), indexText(*index.To))
+}
+
+func (fk ForeignKeyDiff) Summary() string {
+	switch fk.Kind {
+	case Added:
+		return "+ foreign key " + foreignKeyText(*fk.To)
+	case Removed:
+		return "- foreign key " + foreignKeyText(*fk.From)
+	}
+
+	return fmt.Sprintf("~ foreign key %s becomes %s", foreignKeyText(*fk.From), foreignKeyText(*fk.To))
+}
+
+// baseTables maps the tables in tables by name, other object types are
+// skipped.
+func baseTables(tables []Table) map[string]*Table {
+	tableMap := make(map[string]*Table)
+	for i, v := range tables {
+		if v.Type == TableObject {
+			tableMap[v.Name] = &tables[i]
+		}
+	}
+
+	return tableMap
+}
+
+func diffColumns(from, to *Table) []ColumnDiff {
+	var diffs []ColumnDiff
+	for i, v := range to.Columns {
+		fromCol := from.column(v.Name)
+		if fromCol == nil {
+			diffs = append(diffs, ColumnDiff{Name: v.Name, Kind: Added, To: &to.Columns[i]})
+			continue
+		}
+
+		changes := columnChanges(*fromCol, v)
+		if len(changes) > 0 {
+			diffs = append(diffs, ColumnDiff{Name: v.Name, Kind: Changed, From: fromCol, To: &to.Columns[i], Changes: changes})
+		}
+	}
+
+	for i, v := range from.Columns {
+		if to.column(v.Name) == nil {
+			diffs = append(diffs, ColumnDiff{Name: v.Name, Kind: Removed, From: &from.Columns[i]})
+		}
+	}
+
+	return diffs
+}
+
+func columnChanges(from, to Column) []string {
+	var changes []string
+	if !sameType(from.Type, to.Type) {
+		changes = append(changes, fmt.Sprintf("type %s → %s", from.Type, to.Type))
+	}
+
+	if from.NotNull != to.NotNull {
+		if to.NotNull {
+			changes = append(changes, "NOT NULL added")
+		} else {
+			changes = append(changes, "NOT NULL dropped")
+		}
+	}
+
+	if defaultText(from.DefaultValue) != defaultText(to.DefaultValue) {
+		changes = append(changes, fmt.Sprintf("default %s → %s", defaultText(from.DefaultValue), defaultText(to.DefaultValue)))
+	}
+
+	if from.PrimaryKey != to.PrimaryKey {
+		if to.PrimaryKey {
+			changes = append(changes, "added to the primary key")
+		} else {
+			changes = append(changes, "removed from the primary key")
+		}
+	}
+
+	return changes
+}
+
+// diffIndexes compares the indexes on two tables by name, indexes that only
+// exist to back the primary key are skipped since their names are made up
+// by the database.
+func diffIndexes(from, to *Table) []IndexDiff {
+	var diffs []IndexDiff
+	for i, v := range to.Indexes {
+		if to.constraintIndex(v) {
+			continue
+		}
+
+		fromIndex := from.index(v.Name)
+		switch {
+		case fromIndex == nil:
+			diffs = append(diffs, IndexDiff{Name: v.Name, Kind: Added, To: &to.Indexes[i]})
+		case indexText(*fromIndex) != indexText(v):
+			diffs = append(diffs, IndexDiff{Name: v.Name, Kind: Changed, From: fromIndex, To: &to.Indexes[i]})
+		}
+	}
+
+	for i, v := range from.Indexes {
+		if !from.constraintIndex(v) && to.index(v.Name) == nil {
+			diffs = append(diffs, IndexDiff{Name: v.Name, Kind: Removed, From: &from.Indexes[i]})
+		}
+	}
+
+	return diffs
+}
+
+func diffForeignKeys(from, to *Table) []ForeignKeyDiff {
+	var diffs []ForeignKeyDiff
+	for i, v := range to.ForeignKeys {
+		fromFK := from.foreignKey(v)
+		switch {
+		case fromFK == nil:
+			diffs = append(diffs, ForeignKeyDiff{Kind: Added, To: &to.ForeignKeys[i]})
+		case foreignKeyText(*fromFK) != foreignKeyText(v):
+			diffs = append(diffs, ForeignKeyDiff{Kind: Changed, From: fromFK, To: &to.ForeignKeys[i]})
+		}
+	}
+
+	for i, v := range from.ForeignKeys {
+		if to.foreignKey(v) == nil {
+			diffs = append(diffs, ForeignKeyDiff{Kind: Removed, From: &from.ForeignKeys[i]})
+		}
+	}
+
+	return diffs
+}
+
+func (table *Table) column(name string) *Column {
+	for i, v := range table.Columns {
+		if v.Name == name {
+			return &table.Columns[i]
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) index(name string) *Index {
+	for i, v := range table.Indexes {
+		if v.Name == name && !table.constraintIndex(v) {
+			return &table.Indexes[i]
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) foreignKey(fk ForeignKey) *ForeignKey {
+	for i, v := range table.ForeignKeys {
+		if v.RefTable == fk.RefTable && slices.Equal(v.Columns, fk.Columns) {
+			return &table.ForeignKeys[i]
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) primaryKey() []string {
+	var cols []string
+	for _, v := range table.Columns {
+		if v.PrimaryKey {
+			cols = append(cols, v.Name)
+		}
+	}
+
+	return cols
+}
+
+func (table *Table) constraintIndex(index Index) bool {
+	if strings.HasPrefix(index.Name, "sqlite_autoindex_") || index.Name == "PRIMARY" {
+		return true
+	}
+
+	pk := table.primaryKey()
+	return len(pk) > 0 && index.unique() && slices.Equal(index.columns(), pk)
+}
+
+func (index Index) unique() bool {
+	return len(index.Cols) > 0 && index.Cols[0].Unique
+}
+
+func (index Index) partial() bool {
+	return len(index.Cols) > 0 && index.Cols[0].Partial
+}
+
+func (index Index) columns() []string {
+	var cols []string
+	for _, v := range index.Cols {
+		cols = append(cols, v.ColumnName)
+	}
+
+	return cols
+}
+
+func indexText(index Index) string {
+	text := "(" + strings.Join(index.columns(), ", ") + ")"
+	if index.unique() {
+		text = "UNIQUE " + text
+	}
+
+	return text
+}
+
+func foreignKeyText(fk ForeignKey) string {
+	text := fmt.Sprintf("(%s) → %s", strings.Join(fk.Columns, ", "), fk.RefTable)
+	if slices.ContainsFunc(fk.RefColumns, func(col string) bool { return col != "" }) {
+		text += " (" + strings.Join(fk.RefColumns, ", ") + ")"
+	}
+
+	return text + foreignKeyActions(fk)
+}
+
+func foreignKeyActions(fk ForeignKey) string {
+	var text string
+	if fk.OnUpdate != "" && fk.OnUpdate != "NO ACTION" {
+		text += " ON UPDATE " + fk.OnUpdate
+	}
+	if fk.OnDelete != "" && fk.OnDelete != "NO ACTION" {
+		text += " ON DELETE " + fk.OnDelete
+	}
+
+	return text
+}
+
+// sameType compares column types ignoring case and spacing.
+func sameType(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+func defaultText(value *string) string {
+	if value == nil {
+		return "none"
+	}
+
+	return *value
+}
+
+// migration builds the DDL for a SchemaDiff in the dialect of driver.
+type migration struct {
+	driver string
+	lines  []string
+	names  map[string]bool
+}
+
+// MigrationSQL returns the DDL that turns the From schema into the To
+// schema, driver is the name of the driver for the database the DDL will be
+// run against. Changes that can't be made safely, like swapping a primary
+// key outside of Sqlite, are left as comments to be done by hand.
+func (diff SchemaDiff) MigrationSQL(driver string) string {
+	m := &migration{driver: driver, names: diff.names}
+	sqlite := driver == "sqlite3"
+
+	// Sqlite can't alter columns or constraints so those tables are
+	// recreated with the new definition and their data copied over.
+	rebuilt := make(map[string]bool)
+	for _, v := range diff.Tables {
+		if sqlite && v.Kind == Changed && v.needsRebuild() {
+			rebuilt[v.Name] = true
+		}
+	}
+	if len(rebuilt) > 0 {
+		m.add("PRAGMA foreign_keys = OFF;")
+	}
+
+	for _, v := range diff.Tables {
+		if sqlite || v.Kind == Added {
+			continue
+		}
+
+		for _, fk := range v.ForeignKeys {
+			if fk.Kind != Added {
+				m.dropForeignKey(v.Name, *fk.From)
+			}
+		}
+	}
+
+	for _, v := range diff.Tables {
+		if v.Kind != Changed || rebuilt[v.Name] {
+			continue
+		}
+
+		for _, index := range v.Indexes {
+			if index.Kind != Added {
+				m.dropIndex(v.Name, index.Name)
+			}
+		}
+	}
+
+	for _, v := range diff.Tables {
+		if v.Kind == Removed {
+			m.add("DROP TABLE %s;", m.table(v.Name))
+		}
+	}
+
+	for _, v := range diff.Tables {
+		if v.Kind == Added {
+			m.createTable(v.Name, v.To, sqlite)
+		}
+	}
+
+	for _, v := range diff.Tables {
+		switch {
+		case v.Kind != Changed:
+			continue
+		case rebuilt[v.Name]:
+			m.rebuildTable(v)
+		default:
+			m.alterTable(v)
+		}
+	}
+
+	for _, v := range diff.Tables {
+		switch {
+		case rebuilt[v.Name]:
+			for _, index := range v.To.Indexes {
+				if !v.To.constraintIndex(index) {
+					m.createIndex(v.Name, index)
+				}
+			}
+		case v.Kind != Removed:
+			for _, index := range v.Indexes {
+				if index.Kind != Removed {
+					m.createIndex(v.Name, *index.To)
+				}
+			}
+		}
+	}
+
+	for _, v := range diff.Tables {
+		if sqlite || v.Kind == Removed {
+			continue
+		}
+
+		for _, fk := range v.ForeignKeys {
+			if fk.Kind != Removed {
+				m.add("ALTER TABLE %s ADD %s;", m.table(v.Name), m.foreignKeyDef(*fk.To))
+			}
+		}
+	}
+
+	if len(rebuilt) > 0 {
+		m.add("PRAGMA foreign_keys = ON;")
+	}
+
+	if len(m.lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(m.lines, "\n") + "\n"
+}
+
+// needsRebuild reports whether Sqlite has to recreate the table, it can only
+// add columns that aren't part of the primary key and either allow NULL or
+// have a default.
+func (table TableDiff) needsRebuild() bool {
+	if len(table.ForeignKeys) > 0 {
+		return true
+	}
+
+	for _, v := range table.Columns {
+		if v.Kind != Added || v.To.PrimaryKey || (v.To.NotNull && v.To.DefaultValue == nil) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *migration) add(format string, args ...any) {
+	m.lines = append(m.lines, fmt.Sprintf(format, args...))
+}
+
+func (m *migration) note(format string, args ...any) {
+	m.lines = append(m.lines, "-- "+fmt.Sprintf(format, args...))
+}
+
+func (m *migration) quote(name string) string {
+	switch m.driver {
+	case "mysql":
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case "sqlserver":
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// table quotes a table name, Postgres and SQL Server tables outside of the
+// default schema are named schema.table.
+func (m *migration) table(name string) string {
+	if m.driver == "postgres" || m.driver == "sqlserver" {
+		if schema, table, ok := strings.Cut(name, "."); ok {
+			return m.quote(schema) + "." + m.quote(table)
+		}
+	}
+
+	return m.quote(name)
+}
+
+// indexColumn quotes an index column, expressions are left as they are.
+func (m *migration) indexColumn(col string) string {
+	if identifierRegex.MatchString(col) {
+		return m.quote(col)
+	}
+
+	return col
+}
+
+func (m *migration) quoteAll(names []string) string {
+	var quoted []string
+	for _, v := range names {
+		quoted = append(quoted, m.quote(v))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func (m *migration) columnDef(col Column) string {
+	def := m.quote(col.Name)
+	if col.Type != "" {
+		def += " " + col.Type
+	}
+	if col.NotNull {
+		def += " NOT NULL"
+	}
+	if col.DefaultValue != nil {
+		def += " DEFAULT " + *col.DefaultValue
+	}
+
+	return def
+}
+
+// foreignKeyDef is the constraint clause for fk, it keeps its name unless it
+// came from Sqlite where the name is just a number.
+func (m *migration) foreignKeyDef(fk ForeignKey) string {
+	var def string
+	if m.driver != "sqlite3" && fk.ID != "" && identifierRegex.MatchString(fk.ID) {
+		def = "CONSTRAINT " + m.quote(fk.ID) + " "
+	}
+
+	def += fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s", m.quoteAll(fk.Columns), m.table(fk.RefTable))
+	if !slices.Contains(fk.RefColumns, "") {
+		def += fmt.Sprintf(" (%s)", m.quoteAll(fk.RefColumns))
+	}
+
+	return def + foreignKeyActions(fk)
+}
+
+// createTable creates table under name, foreign keys are only added inline
+// when foreignKeys is set, migrations outside of Sqlite add them once every
+// table exists.
+func (m *migration) createTable(name string, table *Table, foreignKeys bool) {
+	var defs []string
+	for _, v := range table.Columns {
+		defs = append(defs, m.columnDef(v))
+	}
+
+	if pk := table.primaryKey(); len(pk) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", m.quoteAll(pk)))
+	}
+
+	if foreignKeys {
+		for _, v := range table.ForeignKeys {
+			defs = append(defs, m.foreignKeyDef(v))
+		}
+	}
+
+	m.add("CREATE TABLE %s (\n\t%s\n);", m.table(name), strings.Join(defs, ",\n\t"))
+}
+
+func (m *migration) rebuildTable(table TableDiff) {
+	tmp := m.freeName(table.Name + "_new")
+	m.createTable(tmp, table.To, true)
+
+	var copied []string
+	for _, v := range table.To.Columns {
+		if table.From.column(v.Name) != nil {
+			copied = append(copied, v.Name)
+		}
+	}
+
+	for _, v := range table.Columns {
+		if v.Kind == Added && v.To.NotNull && v.To.DefaultValue == nil {
+			m.note("%s.%s is NOT NULL without a default, the copy below fails if %s has rows", table.Name, v.Name, table.Name)
+		}
+	}
+
+	if len(copied) > 0 {
+		m.add("INSERT INTO %s (%s) SELECT %s FROM %s;", m.quote(tmp), m.quoteAll(copied), m.quoteAll(copied), m.quote(table.Name))
+	}
+	m.add("DROP TABLE %s;", m.quote(table.Name))
+	m.add("ALTER TABLE %s RENAME TO %s;", m.quote(tmp), m.quote(table.Name))
+
+	for _, v := range table.From.Triggers {
+		m.note("trigger %s was dropped along with %s and needs to be recreated", v.Name, table.Name)
+	}
+}
+
+// freeName returns name, or name with a number after it, so it isn't the
+// name of anything in either schema. The name is then taken.
+func (m *migration) freeName(name string) string {
+	if m.names == nil {
+		m.names = make(map[string]bool)
+	}
+
+	free := name
+	for i := 2; m.names[strings.ToLower(free)]; i++ {
+		free = fmt.Sprintf("%s%d", name, i)
+	}
+
+	m.names[strings.ToLower(free)] = true
+	return free
+}
+
+func (m *migration) alterTable(table TableDiff) {
+	name := m.table(table.Name)
+	pkChanged := false
+
+	for _, v := range table.Columns {
+		switch v.Kind {
+		case Added:
+			if m.driver == "sqlserver" {
+				m.add("ALTER TABLE %s ADD %s;", name, m.columnDef(*v.To))
+			} else {
+				m.add("ALTER TABLE %s ADD COLUMN %s;", name, m.columnDef(*v.To))
+			}
+			pkChanged = pkChanged || v.To.PrimaryKey
+		case Changed:
+			m.alterColumn(name, *v.From, *v.To)
+			pkChanged = pkChanged || v.From.PrimaryKey != v.To.PrimaryKey
+		}
+	}
+
+	for _, v := range table.Columns {
+		if v.Kind == Removed {
+			m.add("ALTER TABLE %s DROP COLUMN %s;", name, m.quote(v.Name))
+			pkChanged = pkChanged || v.From.PrimaryKey
+		}
+	}
+
+	if pkChanged {
+		m.note("the primary key of %s changes to (%s), swap the constraint by hand", table.Name, strings.Join(table.To.primaryKey(), ", "))
+	}
+}
+
+func (m *migration) alterColumn(table string, from, to Column) {
+	col := m.quote(to.Name)
+	typeChanged := !sameType(from.Type, to.Type)
+	defaultChanged := defaultText(from.DefaultValue) != defaultText(to.DefaultValue)
+
+	switch m.driver {
+	case "postgres":
+		if typeChanged {
+			m.add("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, col, to.Type)
+		}
+		if from.NotNull != to.NotNull && to.NotNull {
+			m.add("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, col)
+		} else if from.NotNull != to.NotNull {
+			m.add("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, col)
+		}
+		if defaultChanged && to.DefaultValue != nil {
+			m.add("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, col, *to.DefaultValue)
+		} else if defaultChanged {
+			m.add("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, col)
+		}
+	case "mysql":
+		if typeChanged || from.NotNull != to.NotNull || defaultChanged {
+			m.add("ALTER TABLE %s MODIFY COLUMN %s;", table, m.columnDef(to))
+		}
+	case "sqlserver":
+		if typeChanged || from.NotNull != to.NotNull {
+			null := " NULL"
+			if to.NotNull {
+				null = " NOT NULL"
+			}
+			m.add("ALTER TABLE %s ALTER COLUMN %s %s%s;", table, col, to.Type, null)
+		}
+		if defaultChanged && from.DefaultValue != nil {
+			m.note("drop the default constraint on %s.%s", table, to.Name)
+		}
+		if defaultChanged && to.DefaultValue != nil {
+			m.add("ALTER TABLE %s ADD DEFAULT %s FOR %s;", table, *to.DefaultValue, col)
+		}
+	}
+}
+
+func (m *migration) dropForeignKey(table string, fk ForeignKey) {
+	switch {
+	case !identifierRegex.MatchString(fk.ID):
+		m.note("drop the foreign key %s on %s", foreignKeyText(fk), table)
+	case m.driver == "mysql":
+		m.add("ALTER TABLE %s DROP FOREIGN KEY %s;", m.table(table), m.quote(fk.ID))
+	default:
+		m.add("ALTER TABLE %s DROP CONSTRAINT %s;", m.table(table), m.quote(fk.ID))
+	}
+}
+
+// dropIndex drops an index on table, Postgres indexes live in the schema of
+// their table and are dropped by name alone.
+func (m *migration) dropIndex(table, index string) {
+	switch m.driver {
+	case "mysql", "sqlserver":
+		m.add("DROP INDEX %s ON %s;", m.quote(index), m.table(table))
+	case "postgres":
+		if schema, _, ok := strings.Cut(table, "."); ok {
+			m.add("DROP INDEX %s.%s;", m.quote(schema), m.quote(index))
+			return
+		}
+		fallthrough
+	default:
+		m.add("DROP INDEX %s;", m.quote(index))
+	}
+}
+
+func (m *migration) createIndex(table string, index Index) {
+	var cols []string
+	for _, v := range index.columns() {
+		cols = append(cols, m.indexColumn(v))
+	}
+
+	unique := ""
+	if index.unique() {
+		unique = "UNIQUE "
+	}
+
+	if index.partial() {
+		m.note("%s is a partial index, add its WHERE clause", index.Name)
+	}
+	m.add("CREATE %sINDEX %s ON %s (%s);", unique, m.quote(index.Name), m.table(table), strings.Join(cols, ", "))
+}
+
+// fillDefinitions builds the CREATE statements for tables and indexes the
+// database doesn't keep the SQL for, driver picks the dialect.
+func fillDefinitions(tables []Table, driver string) {
+	for i, v := range tables {
+		if v.Type == TableObject && v.Definition == "" {
+			m := &migration{driver: driver}
+			m.createTable(v.Name, &tables[i], true)
+			tables[i].Definition = strings.Join(m.lines, "\n")
+		}
+
+		for j, index := range v.Indexes {
+			if index.Definition == "" && !v.constraintIndex(index) {
+				m := &migration{driver: driver}
+				m.createIndex(v.Name, index)
+				tables[i].Indexes[j].Definition = strings.Join(m.lines, "\n")
+			}
+		}
+	}
+}
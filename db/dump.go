@@ -0,0 +1,356 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// dumpPageSize is how many rows are read from a table at a time while it's
+// written out.
+const dumpPageSize = 500
+
+// DumpProgress is called before each table's rows are written, done is how
+// many tables have been written so far.
+type DumpProgress func(table string, done, total int)
+
+// Dump writes a SQL script that recreates every table, index, view and
+// trigger from GetTables and fills the tables with INSERT statements. Tables
+// are written after the tables they reference so the script can be run on
+// an empty database, Sqlite and MySQL also have foreign key checks turned
+// off while it runs so cycles load there. Postgres sequences are created
+// before the tables and set to their current values after the rows.
+func Dump(ctx context.Context, w io.Writer, database Database, progress DumpProgress) error {
+	tables, err := database.GetTables()
+	if err != nil {
+		return err
+	}
+
+	var sequences []Sequence
+	if source, ok := database.(sequenceSource); ok {
+		sequences, err = source.GetSequences()
+		if err != nil {
+			return err
+		}
+	}
+
+	driver, _ := database.Info()
+	d := &dumper{
+		m:   &migration{driver: driver},
+		buf: bufio.NewWriter(w),
+	}
+
+	data := dumpTables(tables, driver)
+
+	d.line("-- Dumped by sqline from a %s database", driver)
+	switch driver {
+	case "sqlite3":
+		d.line("PRAGMA foreign_keys = OFF;")
+		d.line("BEGIN TRANSACTION;")
+	case "postgres":
+		d.line("BEGIN;")
+	case "mysql":
+		d.line("SET FOREIGN_KEY_CHECKS = 0;")
+	}
+
+	for _, v := range sequences {
+		if v.Identity == "" {
+			d.statement(v.Definition)
+			if v.TableName == "" {
+				d.setval(v)
+			}
+		}
+	}
+
+	for i, v := range data {
+		if progress != nil {
+			progress(v.Name, i, len(data))
+		}
+
+		if !strings.HasPrefix(v.Name, "sqlite_") {
+			d.line("")
+			d.statement(v.Definition)
+		}
+
+		if v.Name == "sqlite_sequence" {
+			d.line("DELETE FROM sqlite_sequence;")
+		}
+
+		owned := ownedSequences(sequences, v.Name)
+		for _, seq := range owned {
+			if seq.Identity == "ALWAYS" {
+				d.line("ALTER TABLE %s ALTER COLUMN %s SET GENERATED BY DEFAULT;", d.m.table(v.Name), d.m.quote(seq.ColumnName))
+			}
+		}
+
+		err = d.rows(ctx, database, v.Name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.Name, err)
+		}
+
+		for _, seq := range owned {
+			switch seq.Identity {
+			case "":
+				d.line("ALTER SEQUENCE %s OWNED BY %s.%s;", d.m.table(seq.Name), d.m.table(v.Name), d.m.quote(seq.ColumnName))
+			case "ALWAYS":
+				d.line("ALTER TABLE %s ALTER COLUMN %s SET GENERATED ALWAYS;", d.m.table(v.Name), d.m.quote(seq.ColumnName))
+			}
+			d.setval(seq)
+		}
+	}
+
+	if progress != nil {
+		progress("", len(data), len(data))
+	}
+
+	d.line("")
+	for _, v := range data {
+		for _, index := range v.Indexes {
+			if index.Definition != "" && !v.constraintIndex(index) && !d.inlineIndex(v, index) {
+				d.statement(index.Definition)
+			}
+		}
+	}
+
+	for _, v := range tables {
+		if v.Type == ViewObject {
+			d.batch(v.Definition)
+		}
+	}
+
+	for _, v := range tables {
+		for _, trigger := range v.Triggers {
+			d.batch(trigger.Definition)
+		}
+	}
+
+	switch driver {
+	case "sqlite3", "postgres":
+		d.line("COMMIT;")
+	case "mysql":
+		d.line("SET FOREIGN_KEY_CHECKS = 1;")
+	}
+
+	if d.err != nil {
+		return d.err
+	}
+
+	return d.buf.Flush()
+}
+
+// dumpTables picks the tables that hold rows, Sqlite's internal tables and
+// the shadow tables behind virtual tables are left out since they're made
+// along with the tables that own them, except for sqlite_sequence which
+// keeps the AUTOINCREMENT counters. Tables come after the tables their
+// foreign keys reference, sqlite_sequence goes last since inserting into a
+// table with AUTOINCREMENT writes to it and it only exists once one of
+// those tables has been made.
+func dumpTables(tables []Table, driver string) []Table {
+	var virtual []string
+	for _, v := range tables {
+		if v.Type == VirtualTableObject {
+			virtual = append(virtual, v.Name+"_")
+		}
+	}
+
+	var data []Table
+	for _, v := range tables {
+		shadow := slices.ContainsFunc(virtual, func(prefix string) bool {
+			return strings.HasPrefix(v.Name, prefix)
+		})
+
+		switch {
+		case v.Type == ViewObject, shadow:
+			continue
+		case strings.HasPrefix(v.Name, "sqlite_") && v.Name != "sqlite_sequence":
+			continue
+		}
+
+		if v.Definition == "" {
+			m := &migration{driver: driver}
+			m.createTable(v.Name, &v, true)
+			v.Definition = strings.Join(m.lines, "\n")
+		}
+
+		data = append(data, v)
+	}
+
+	slices.SortStableFunc(data, func(a, b Table) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	data = referencedFirst(data)
+	i := slices.IndexFunc(data, func(v Table) bool {
+		return v.Name == "sqlite_sequence"
+	})
+	if i != -1 {
+		sequence := data[i]
+		data = append(slices.Delete(data, i, i+1), sequence)
+	}
+
+	return data
+}
+
+// referencedFirst orders tables so each one comes after the tables it
+// references, tables in a cycle stay in the order they were in.
+func referencedFirst(tables []Table) []Table {
+	index := make(map[string]int)
+	for i, v := range tables {
+		index[v.Name] = i
+	}
+
+	var sorted []Table
+	state := make([]byte, len(tables))
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != 0 {
+			return
+		}
+
+		state[i] = 1
+		for _, fk := range tables[i].ForeignKeys {
+			if j, ok := index[fk.RefTable]; ok {
+				visit(j)
+			}
+		}
+
+		state[i] = 2
+		sorted = append(sorted, tables[i])
+	}
+
+	for i := range tables {
+		visit(i)
+	}
+
+	return sorted
+}
+
+// Sequence is a Postgres sequence, TableName and ColumnName are set when a
+// column owns it and Identity is ALWAYS or BY DEFAULT when it's behind an
+// identity column. Definition creates a sequence that isn't an identity.
+type Sequence struct {
+	Name       string `db:"Name"`
+	TableName  string `db:"TableName"`
+	ColumnName string `db:"ColumnName"`
+	Identity   string `db:"Identity"`
+	Definition string `db:"Definition"`
+	LastValue  *int64 `db:"LastValue"`
+}
+
+// sequenceSource can be implemented by a Database whose ids come from
+// sequences kept apart from the tables.
+type sequenceSource interface {
+	GetSequences() ([]Sequence, error)
+}
+
+// ownedSequences returns the sequences owned by the columns of table.
+func ownedSequences(sequences []Sequence, table string) []Sequence {
+	var owned []Sequence
+	for _, v := range sequences {
+		if v.TableName == table {
+			owned = append(owned, v)
+		}
+	}
+
+	return owned
+}
+
+type dumper struct {
+	m   *migration
+	buf *bufio.Writer
+	err error
+}
+
+func (d *dumper) line(format string, args ...any) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.buf, format+"\n", args...)
+	}
+}
+
+// statement writes sql ending with a semicolon, the definitions from the
+// catalogs don't always have one.
+func (d *dumper) statement(sql string) {
+	sql = strings.TrimSpace(sql)
+	if sql == "" {
+		return
+	}
+
+	if !strings.HasSuffix(sql, ";") {
+		sql += ";"
+	}
+
+	d.line("%s", sql)
+}
+
+// setval moves seq on to the value it had, identity sequences are looked up
+// through their column since the name they get when the table is made can
+// differ.
+func (d *dumper) setval(seq Sequence) {
+	if seq.LastValue == nil {
+		return
+	}
+
+	name := quoteLiteral(d.m.table(seq.Name))
+	if seq.Identity != "" {
+		name = fmt.Sprintf("pg_get_serial_sequence(%s, %s)", quoteLiteral(d.m.table(seq.TableName)), quoteLiteral(seq.ColumnName))
+	}
+
+	d.line("SELECT setval(%s, %d);", name, *seq.LastValue)
+}
+
+// batch writes a view or trigger, SQL Server needs them to be alone in their
+// batch so they're followed by GO.
+func (d *dumper) batch(sql string) {
+	if strings.TrimSpace(sql) == "" {
+		return
+	}
+
+	d.line("")
+	if d.m.driver == "sqlserver" {
+		d.line("GO")
+		d.line("%s", strings.TrimSpace(sql))
+		d.line("GO")
+		return
+	}
+
+	d.statement(sql)
+}
+
+// inlineIndex reports whether index is already part of the table
+// definition, Postgres has the ones made by UNIQUE constraints and MySQL's
+// SHOW CREATE TABLE lists every index as a KEY.
+func (d *dumper) inlineIndex(table Table, index Index) bool {
+	switch d.m.driver {
+	case "postgres":
+		return strings.Contains(table.Definition, "CONSTRAINT "+d.m.quote(index.Name)+" ")
+	case "mysql":
+		return strings.Contains(table.Definition, "KEY "+d.m.quote(index.Name)+" (")
+	}
+
+	return false
+}
+
+func (d *dumper) rows(ctx context.Context, database Database, table string) error {
+	rs, err := database.Select(ctx, "SELECT * FROM "+d.m.table(table))
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+
+	for d.err == nil {
+		page, err := rs.Next(dumpPageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+
+		d.err = exportInserts(d.buf, rs.Columns(), page, table, d.m.driver)
+	}
+
+	return d.err
+}
@@ -0,0 +1,438 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrNotEditable = errors.New("only rows from a SELECT on a single table can be edited")
+
+// maxDeleteRows keeps a DELETE under SQL Server's limit of 2100 parameters
+// for keys of up to two columns.
+const maxDeleteRows = 1000
+
+// EditTarget is the table a result's rows were selected from. Columns has the
+// table column behind each result column, empty for expressions, and Keys
+// has the result column of each primary key column.
+type EditTarget struct {
+	Table   Table
+	Columns []string
+	Keys    []int
+}
+
+// BoundStatement is a statement with its bind arguments, the placeholders
+// are in the style of the driver it was built for.
+type BoundStatement struct {
+	SQL  string
+	Args []any
+	m    *migration
+}
+
+// FindEditTarget works out which table the rows from stmt came from so they
+// can be written back. stmt has to be a plain SELECT from one table, without
+// joins, grouping or set operations, that returns every column of the
+// table's primary key. columns are the result's columns.
+func FindEditTarget(driver, stmt string, tables []Table, columns []ColumnInfo) (*EditTarget, error) {
+	var tokens []Token
+	for _, v := range Tokenize(driver, stmt) {
+		if v.Type != CommentToken && !v.isPunct(";") {
+			tokens = append(tokens, v)
+		}
+	}
+
+	if len(tokens) == 0 || !tokens[0].isWord("SELECT") {
+		return nil, ErrNotEditable
+	}
+
+	depth, from := 0, -1
+	for i, v := range tokens {
+		switch {
+		case v.isPunct("("):
+			depth++
+		case v.isPunct(")"):
+			depth--
+		case depth != 0:
+		case i > 0 && v.isWord("SELECT", "JOIN", "UNION", "INTERSECT", "EXCEPT", "GROUP", "HAVING", "WINDOW", "INTO"):
+			return nil, ErrNotEditable
+		case v.isWord("FROM") && from < 0:
+			from = i
+		}
+	}
+
+	if from < 0 {
+		return nil, ErrNotEditable
+	}
+
+	name, rest := tableReference(tokens[from+1:])
+	if name == "" {
+		return nil, ErrNotEditable
+	}
+	if len(rest) > 0 && !rest[0].isWord("WHERE", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR") {
+		return nil, ErrNotEditable
+	}
+
+	table := editTable(driver, tables, name)
+	if table == nil {
+		return nil, fmt.Errorf("%s isn't a table so its rows can't be edited", name)
+	}
+
+	target := &EditTarget{Table: *table}
+	for _, item := range selectItems(tokens[1:from]) {
+		switch {
+		case len(item) == 1 && item[0].isPunct("*"),
+			len(item) == 3 && item[1].isPunct(".") && item[2].isPunct("*"):
+			for _, v := range table.Columns {
+				target.Columns = append(target.Columns, v.Name)
+			}
+		case len(item) == 1 && isIdentifier(item[0]):
+			target.Columns = append(target.Columns, table.columnName(identName(item[0])))
+		case len(item) == 3 && isIdentifier(item[0]) && item[1].isPunct(".") && isIdentifier(item[2]):
+			target.Columns = append(target.Columns, table.columnName(identName(item[2])))
+		default:
+			target.Columns = append(target.Columns, "")
+		}
+	}
+
+	if len(target.Columns) != len(columns) {
+		return nil, ErrNotEditable
+	}
+
+	for i, v := range columns {
+		if !strings.EqualFold(v.Name, target.Columns[i]) {
+			target.Columns[i] = ""
+		}
+	}
+
+	for _, col := range table.Columns {
+		if !col.PrimaryKey {
+			continue
+		}
+
+		key := -1
+		for j, v := range target.Columns {
+			if v == col.Name {
+				key = j
+				break
+			}
+		}
+		if key < 0 {
+			return nil, fmt.Errorf("the primary key column %s isn't in the results so rows can't be matched to edit them", col.Name)
+		}
+
+		target.Keys = append(target.Keys, key)
+	}
+
+	if len(target.Keys) == 0 {
+		return nil, fmt.Errorf("%s has no primary key so its rows can't be edited", table.Name)
+	}
+
+	return target, nil
+}
+
+// Editable reports why the result column col can't be written back, it's nil
+// if it can.
+func (target *EditTarget) Editable(col int, columns []ColumnInfo) error {
+	if col < 0 || col >= len(target.Columns) || col >= len(columns) {
+		return ErrNotEditable
+	}
+
+	if target.Columns[col] == "" {
+		return fmt.Errorf("%s isn't a column of %s so it can't be edited", columns[col].Name, target.Table.Name)
+	}
+
+	if isBinaryType(columns[col].DatabaseType) {
+		return fmt.Errorf("%s holds binary data which can't be edited as text", target.Columns[col])
+	}
+
+	return nil
+}
+
+// Update builds the UPDATE that sets the result column col of row to value,
+// a nil value sets NULL. The row is matched by its primary key.
+func (target *EditTarget) Update(driver string, row Row, col int, value *string) (BoundStatement, error) {
+	stmt := BoundStatement{m: &migration{driver: driver}}
+
+	var arg any
+	if value != nil {
+		arg = *value
+	}
+
+	set := fmt.Sprintf("%s = %s", stmt.m.quote(target.Columns[col]), stmt.bind(arg))
+	where, err := target.match(&stmt, row)
+	if err != nil {
+		return stmt, err
+	}
+
+	stmt.SQL = fmt.Sprintf("UPDATE %s SET %s WHERE %s", stmt.m.table(target.Table.Name), set, where)
+	return stmt, nil
+}
+
+// Delete builds the DELETE that removes rows, matching each by its primary
+// key. A single column key is matched with IN.
+func (target *EditTarget) Delete(driver string, rows []Row) (BoundStatement, error) {
+	stmt := BoundStatement{m: &migration{driver: driver}}
+	if len(rows) == 0 {
+		return stmt, errors.New("no rows to delete")
+	}
+	if len(rows) > maxDeleteRows {
+		return stmt, fmt.Errorf("can't delete more than %d rows at once", maxDeleteRows)
+	}
+
+	var where string
+	if len(target.Keys) == 1 {
+		key := target.Keys[0]
+		var binds []string
+		for _, row := range rows {
+			if row[key].IsNull() {
+				return stmt, fmt.Errorf("a row's %s is NULL so it can't be matched", target.Columns[key])
+			}
+			binds = append(binds, stmt.bind(row[key].Raw))
+		}
+
+		where = fmt.Sprintf("%s IN (%s)", stmt.m.quote(target.Columns[key]), strings.Join(binds, ", "))
+	} else {
+		var conds []string
+		for _, row := range rows {
+			cond, err := target.match(&stmt, row)
+			if err != nil {
+				return stmt, err
+			}
+			conds = append(conds, "("+cond+")")
+		}
+
+		where = strings.Join(conds, " OR ")
+	}
+
+	stmt.SQL = fmt.Sprintf("DELETE FROM %s WHERE %s", stmt.m.table(target.Table.Name), where)
+	return stmt, nil
+}
+
+// InsertRow builds the INSERT that adds a row to table from values keyed by
+// column name, a nil value inserts NULL. Columns without a value are left out
+// so they take their default.
+func InsertRow(driver string, table Table, values map[string]*string) BoundStatement {
+	stmt := BoundStatement{m: &migration{driver: driver}}
+
+	var cols, binds []string
+	for _, col := range table.Columns {
+		value, ok := values[col.Name]
+		if !ok {
+			continue
+		}
+
+		var arg any
+		if value != nil {
+			arg = *value
+		}
+
+		cols = append(cols, stmt.m.quote(col.Name))
+		binds = append(binds, stmt.bind(arg))
+	}
+
+	name := stmt.m.table(table.Name)
+	switch {
+	case len(cols) > 0:
+		stmt.SQL = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", name, strings.Join(cols, ", "), strings.Join(binds, ", "))
+	case driver == "mysql":
+		stmt.SQL = fmt.Sprintf("INSERT INTO %s () VALUES ()", name)
+	default:
+		stmt.SQL = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", name)
+	}
+
+	return stmt
+}
+
+// match builds the condition that picks out row by its primary key.
+func (target *EditTarget) match(stmt *BoundStatement, row Row) (string, error) {
+	var conds []string
+	for _, v := range target.Keys {
+		if row[v].IsNull() {
+			return "", fmt.Errorf("the row's %s is NULL so it can't be matched", target.Columns[v])
+		}
+
+		conds = append(conds, fmt.Sprintf("%s = %s", stmt.m.quote(target.Columns[v]), stmt.bind(row[v].Raw)))
+	}
+
+	return strings.Join(conds, " AND "), nil
+}
+
+// bind adds arg and returns its placeholder.
+func (stmt *BoundStatement) bind(arg any) string {
+	stmt.Args = append(stmt.Args, arg)
+
+	switch stmt.m.driver {
+	case "postgres":
+		return fmt.Sprintf("$%d", len(stmt.Args))
+	case "sqlserver":
+		return fmt.Sprintf("@p%d", len(stmt.Args))
+	}
+
+	return "?"
+}
+
+// String is the SQL followed by a comment listing the values it's run with.
+func (stmt BoundStatement) String() string {
+	if len(stmt.Args) == 0 {
+		return stmt.SQL + ";"
+	}
+
+	var values []string
+	for _, v := range stmt.Args {
+		values = append(values, stmt.m.literal(Value{Raw: v}))
+	}
+
+	return stmt.SQL + ";\n-- values: " + strings.Join(values, ", ")
+}
+
+// Exec runs the statement against database and returns how many rows it
+// changed.
+func (stmt BoundStatement) Exec(ctx context.Context, database Database) (int64, error) {
+	return database.Exec(ctx, stmt.SQL, stmt.Args...)
+}
+
+// tableReference reads a possibly schema qualified table name and its alias
+// from the start of tokens, name is empty if they don't start with one.
+func tableReference(tokens []Token) (name string, rest []Token) {
+	var parts []string
+	i := 0
+	for i < len(tokens) && isIdentifier(tokens[i]) {
+		parts = append(parts, identName(tokens[i]))
+		i++
+		if i+1 < len(tokens) && tokens[i].isPunct(".") {
+			i++
+			continue
+		}
+		break
+	}
+
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	if i < len(tokens) && tokens[i].isWord("AS") {
+		i++
+	}
+	if i < len(tokens) && isIdentifier(tokens[i]) && !tokens[i].isWord("WHERE", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "NATURAL", "UNION", "GROUP") {
+		i++
+	}
+
+	return strings.Join(parts, "."), tokens[i:]
+}
+
+// selectItems splits a select list on its top level commas, a leading
+// DISTINCT or SQL Server's TOP n are skipped.
+func selectItems(tokens []Token) [][]Token {
+	if len(tokens) > 0 && tokens[0].isWord("DISTINCT", "ALL") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) > 1 && tokens[0].isWord("TOP") {
+		if tokens[1].isPunct("(") {
+			for i, v := range tokens {
+				if v.isPunct(")") {
+					tokens = tokens[i+1:]
+					break
+				}
+			}
+		} else {
+			tokens = tokens[2:]
+		}
+	}
+
+	var items [][]Token
+	var item []Token
+	depth := 0
+	for _, v := range tokens {
+		switch {
+		case v.isPunct("("):
+			depth++
+		case v.isPunct(")"):
+			depth--
+		case depth == 0 && v.isPunct(","):
+			items = append(items, item)
+			item = nil
+			continue
+		}
+
+		item = append(item, v)
+	}
+
+	return append(items, item)
+}
+
+func isIdentifier(tok Token) bool {
+	return tok.Type == WordToken || tok.Type == QuotedIdentToken
+}
+
+// identName removes the quotes from a quoted identifier.
+func identName(tok Token) string {
+	if tok.Type != QuotedIdentToken || len(tok.Text) < 2 {
+		return tok.Text
+	}
+
+	inner := tok.Text[1 : len(tok.Text)-1]
+	switch tok.Text[0] {
+	case '"':
+		return strings.ReplaceAll(inner, `""`, `"`)
+	case '`':
+		return strings.ReplaceAll(inner, "``", "`")
+	}
+
+	return strings.ReplaceAll(inner, "]]", "]")
+}
+
+// defaultSchemas is the schema each driver leaves off the names of its
+// tables.
+var defaultSchemas = map[string]string{
+	"sqlite3":   "main",
+	"postgres":  "public",
+	"sqlserver": "dbo",
+}
+
+// editTable finds the table named name ignoring case. A schema qualified
+// name only matches a table without its schema when the schema is the
+// driver's default or none of the tables have one, so a table in another
+// schema is never taken for the one with the same name in the default.
+func editTable(driver string, tables []Table, name string) *Table {
+	find := func(name string) *Table {
+		for i, v := range tables {
+			if v.Type == TableObject && strings.EqualFold(v.Name, name) {
+				return &tables[i]
+			}
+		}
+
+		return nil
+	}
+
+	if table := find(name); table != nil {
+		return table
+	}
+
+	schema, bare, qualified := strings.Cut(name, ".")
+	if !qualified {
+		return nil
+	}
+
+	unqualified := !slices.ContainsFunc(tables, func(v Table) bool {
+		return strings.Contains(v.Name, ".")
+	})
+	if unqualified || strings.EqualFold(schema, defaultSchemas[driver]) {
+		return find(bare)
+	}
+
+	return nil
+}
+
+// columnName is the table's name for col ignoring case, empty if the table
+// doesn't have it.
+func (table *Table) columnName(col string) string {
+	for _, v := range table.Columns {
+		if strings.EqualFold(v.Name, col) {
+			return v.Name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,350 @@
+package db
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrExportTable  = errors.New("a target table is needed for INSERT statements")
+	ErrExportFormat = errors.New("unknown export format")
+)
+
+type ExportFormat byte
+
+const (
+	CSVExport ExportFormat = iota
+	TSVExport
+	JSONExport
+	MarkdownExport
+	InsertExport
+)
+
+func (format ExportFormat) String() string {
+	switch format {
+	case CSVExport:
+		return "CSV"
+	case TSVExport:
+		return "TSV"
+	case JSONExport:
+		return "JSON"
+	case MarkdownExport:
+		return "Markdown"
+	case InsertExport:
+		return "INSERT"
+	}
+
+	return "Unknown"
+}
+
+// Note is shown with the format when it's offered, CSV has no way to write
+// NULL apart from empty text.
+func (format ExportFormat) Note() string {
+	if format == CSVExport {
+		return "NULL and '' both export as empty fields"
+	}
+
+	return ""
+}
+
+// ExportFormats lists the formats in the order they're offered.
+func ExportFormats() []ExportFormat {
+	return []ExportFormat{CSVExport, TSVExport, JSONExport, MarkdownExport, InsertExport}
+}
+
+// Export writes columns and rows to w in format. NULL is an empty field in
+// CSV, \N in TSV, null in JSON, NULL in Markdown and INSERTs. Table and
+// driver are only used for INSERTs, driver picks how the table name and
+// literals are written.
+func Export(w io.Writer, format ExportFormat, columns []ColumnInfo, rows []Row, table, driver string) error {
+	buf := bufio.NewWriter(w)
+
+	var err error
+	switch format {
+	case CSVExport:
+		err = exportCSV(buf, columns, rows)
+	case TSVExport:
+		err = exportTSV(buf, columns, rows)
+	case JSONExport:
+		err = exportJSON(buf, columns, rows)
+	case MarkdownExport:
+		err = exportMarkdown(buf, columns, rows)
+	case InsertExport:
+		err = exportInserts(buf, columns, rows, table, driver)
+	default:
+		err = ErrExportFormat
+	}
+	if err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+func exportCSV(w io.Writer, columns []ColumnInfo, rows []Row) error {
+	writer := csv.NewWriter(w)
+
+	record := make([]string, len(columns))
+	for i, v := range columns {
+		record[i] = v.Name
+	}
+	writer.Write(record)
+
+	for _, row := range rows {
+		for i, v := range row {
+			record[i] = ""
+			if !v.IsNull() {
+				record[i] = v.String()
+			}
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// tsvEscaper escapes TSV fields the same way Postgres COPY does so tabs and
+// newlines inside a value don't split it.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func exportTSV(w io.Writer, columns []ColumnInfo, rows []Row) error {
+	fields := make([]string, len(columns))
+	for i, v := range columns {
+		fields[i] = tsvEscaper.Replace(v.Name)
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(fields, "\t"))
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		for i, v := range row {
+			fields[i] = `\N`
+			if !v.IsNull() {
+				fields[i] = tsvEscaper.Replace(v.String())
+			}
+		}
+
+		_, err = fmt.Fprintln(w, strings.Join(fields, "\t"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportJSON writes an array with an object per row, the keys are written
+// by hand so they stay in column order.
+func exportJSON(w io.Writer, columns []ColumnInfo, rows []Row) error {
+	keys := make([]string, len(columns))
+	for i, v := range columns {
+		key, err := json.Marshal(v.Name)
+		if err != nil {
+			return err
+		}
+		keys[i] = string(key)
+	}
+
+	_, err := io.WriteString(w, "[")
+	if err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		var fields []string
+		for j, v := range row {
+			value, err := jsonValue(v)
+			if err != nil {
+				return err
+			}
+			fields = append(fields, fmt.Sprintf("%s: %s", keys[j], value))
+		}
+
+		sep := ","
+		if i == 0 {
+			sep = ""
+		}
+
+		_, err = fmt.Fprintf(w, "%s\n  {%s}", sep, strings.Join(fields, ", "))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(rows) > 0 {
+		_, err = io.WriteString(w, "\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
+
+func jsonValue(v Value) (string, error) {
+	switch raw := v.Raw.(type) {
+	case nil:
+		return "null", nil
+	case int64, bool:
+		return fmt.Sprint(raw), nil
+	case float64:
+		if math.IsNaN(raw) || math.IsInf(raw, 0) {
+			break
+		}
+		return strconv.FormatFloat(raw, 'g', -1, 64), nil
+	case string:
+		if v.IsNumber() && json.Valid([]byte(raw)) {
+			return raw, nil
+		}
+	}
+
+	text, err := json.Marshal(v.String())
+	return string(text), err
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+func exportMarkdown(w io.Writer, columns []ColumnInfo, rows []Row) error {
+	cells := make([]string, len(columns))
+	for i, v := range columns {
+		cells[i] = markdownEscaper.Replace(v.Name)
+	}
+
+	_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	if err != nil {
+		return err
+	}
+
+	for i := range cells {
+		cells[i] = "---"
+	}
+
+	_, err = fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		for i, v := range row {
+			cells[i] = markdownEscaper.Replace(v.String())
+		}
+
+		_, err = fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportInserts(w io.Writer, columns []ColumnInfo, rows []Row, table, driver string) error {
+	if strings.TrimSpace(table) == "" {
+		return ErrExportTable
+	}
+
+	m := &migration{driver: driver}
+	names := make([]string, len(columns))
+	for i, v := range columns {
+		names[i] = v.Name
+	}
+
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES", m.table(strings.TrimSpace(table)), m.quoteAll(names))
+	values := make([]string, len(columns))
+	for _, row := range rows {
+		for i, v := range row {
+			values[i] = m.literal(v)
+		}
+
+		_, err := fmt.Fprintf(w, "%s (%s);\n", prefix, strings.Join(values, ", "))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// numberRegex matches the numbers that can be written without quotes, Sqlite
+// lets numeric columns hold any text so those are checked before they're
+// written as is.
+var numberRegex = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// literal writes v as a SQL literal for the migration's driver.
+func (m *migration) literal(v Value) string {
+	switch raw := v.Raw.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(raw, 10)
+	case float64:
+		if math.IsNaN(raw) || math.IsInf(raw, 0) {
+			return quoteLiteral(v.String())
+		}
+		return strconv.FormatFloat(raw, 'g', -1, 64)
+	case bool:
+		switch {
+		case m.driver == "sqlserver" && raw:
+			return "1"
+		case m.driver == "sqlserver":
+			return "0"
+		case raw:
+			return "TRUE"
+		}
+		return "FALSE"
+	case []byte:
+		switch m.driver {
+		case "postgres":
+			return `'\x` + hex.EncodeToString(raw) + `'::bytea`
+		case "sqlserver":
+			return "0x" + hex.EncodeToString(raw)
+		}
+		return "X'" + hex.EncodeToString(raw) + "'"
+	case time.Time:
+		// The offset is written as +00:00 rather than Z since MySQL doesn't
+		// take Z. SQL Server's datetime columns refuse an offset and only
+		// datetimeoffset values come back outside of UTC.
+		if m.driver == "sqlserver" && raw.Location() == time.UTC {
+			return quoteLiteral(raw.Format("2006-01-02 15:04:05.999999999"))
+		}
+		return quoteLiteral(raw.Format("2006-01-02 15:04:05.999999999-07:00"))
+	case string:
+		if v.IsNumber() && numberRegex.MatchString(raw) {
+			return raw
+		}
+	}
+
+	return m.text(v.String())
+}
+
+// text writes a string literal, SQL Server needs the N prefix to keep
+// characters outside of the column's code page and MySQL reads backslashes
+// as escapes.
+func (m *migration) text(s string) string {
+	switch m.driver {
+	case "sqlserver":
+		return "N" + quoteLiteral(s)
+	case "mysql":
+		return quoteLiteral(strings.ReplaceAll(s, `\`, `\\`))
+	}
+
+	return quoteLiteral(s)
+}
+
+func quoteLiteral(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
@@ -0,0 +1,494 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrImportFormat      = errors.New("only CSV and TSV files can be imported")
+	ErrImportEmpty       = errors.New("the file has no header row")
+	ErrImportTable       = errors.New("a table name is needed to import into")
+	ErrImportNoColumns   = errors.New("none of the file's columns are mapped to the table")
+	ErrImportTransaction = errors.New("commit or roll back the open transaction before importing")
+)
+
+// importBatchSize is how many rows go into each INSERT, SQL Server refuses
+// more than 1000 rows in a VALUES list.
+const importBatchSize = 200
+
+// inferSample is how many rows are looked at to pick a new table's column
+// types.
+const inferSample = 1000
+
+// ImportFormats lists the formats that can be read back in.
+func ImportFormats() []ExportFormat {
+	return []ExportFormat{CSVExport, TSVExport}
+}
+
+// FormatForPath guesses the format of a file to import from its extension,
+// anything that isn't .tsv or .tab is read as CSV.
+func FormatForPath(path string) ExportFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".tab":
+		return TSVExport
+	}
+
+	return CSVExport
+}
+
+// ImportData is a delimited file read into memory, the first row is taken as
+// the column names. A nil field is NULL.
+type ImportData struct {
+	Columns []string
+	Rows    [][]*string
+}
+
+// ReadDelimited reads a CSV or TSV file. CSV can't hold NULL so every field
+// is read as text, TSV fields are unescaped the way Export writes them so \N
+// is NULL.
+func ReadDelimited(r io.Reader, format ExportFormat) (*ImportData, error) {
+	var records [][]*string
+	var err error
+	switch format {
+	case CSVExport:
+		records, err = readCSV(r)
+	case TSVExport:
+		records, err = readTSV(r)
+	default:
+		return nil, ErrImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	return &ImportData{
+		Columns: columnNames(records[0]),
+		Rows:    records[1:],
+	}, nil
+}
+
+func readCSV(r io.Reader) ([][]*string, error) {
+	reader := csv.NewReader(r)
+
+	var records [][]*string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		fields := make([]*string, len(record))
+		for i := range record {
+			fields[i] = &record[i]
+		}
+		records = append(records, fields)
+	}
+}
+
+func readTSV(r io.Reader) ([][]*string, error) {
+	reader := bufio.NewReader(r)
+
+	var records [][]*string
+	for line := 1; ; line++ {
+		text, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+		if text != "" || err == nil {
+			fields := strings.Split(text, "\t")
+			if len(records) > 0 && len(fields) != len(records[0]) {
+				return nil, fmt.Errorf("line %d: expected %d fields, got %d", line, len(records[0]), len(fields))
+			}
+
+			record := make([]*string, len(fields))
+			for i, v := range fields {
+				if v != `\N` {
+					value := tsvUnescape(v)
+					record[i] = &value
+				}
+			}
+			records = append(records, record)
+		}
+
+		if err == io.EOF {
+			return records, nil
+		}
+	}
+}
+
+// tsvUnescape reverses tsvEscaper, a backslash before any other character
+// just keeps that character like COPY does.
+func tsvUnescape(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var sb strings.Builder
+	escaped := false
+	for _, ch := range field {
+		if !escaped {
+			if ch == '\\' {
+				escaped = true
+			} else {
+				sb.WriteRune(ch)
+			}
+			continue
+		}
+
+		escaped = false
+		switch ch {
+		case 't':
+			sb.WriteRune('\t')
+		case 'n':
+			sb.WriteRune('\n')
+		case 'r':
+			sb.WriteRune('\r')
+		default:
+			sb.WriteRune(ch)
+		}
+	}
+
+	return sb.String()
+}
+
+// columnNames turns the header row into column names, a byte order mark is
+// dropped, blank names become column_N and repeated names get a suffix.
+func columnNames(header []*string) []string {
+	names := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, v := range header {
+		name := ""
+		if v != nil {
+			name = strings.TrimSpace(*v)
+		}
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+
+		unique := name
+		for n := 2; seen[strings.ToLower(unique)]; n++ {
+			unique = fmt.Sprintf("%s_%d", name, n)
+		}
+
+		seen[strings.ToLower(unique)] = true
+		names[i] = unique
+	}
+
+	return names
+}
+
+type inferredType byte
+
+const (
+	inferNone inferredType = iota
+	inferInteger
+	inferReal
+	inferDate
+	inferTimestamp
+	inferText
+)
+
+var (
+	integerRegex = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)$`)
+	realRegex    = regexp.MustCompile(`^[+-]?((0|[1-9][0-9]*)(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+	timestampLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", time.RFC3339, "2006-01-02 15:04:05Z07:00"}
+)
+
+// inferValue picks the narrowest type value fits. Numbers with leading
+// zeros or too many digits for a bigint are kept as text so codes like 007
+// and long ids aren't changed.
+func inferValue(value string) inferredType {
+	switch {
+	case integerRegex.MatchString(value):
+		_, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			return inferInteger
+		}
+		return inferText
+	case realRegex.MatchString(value):
+		return inferReal
+	}
+
+	_, err := time.Parse("2006-01-02", value)
+	if err == nil {
+		return inferDate
+	}
+
+	for _, layout := range timestampLayouts {
+		_, err = time.Parse(layout, value)
+		if err == nil {
+			return inferTimestamp
+		}
+	}
+
+	return inferText
+}
+
+// widen is the type that fits values of both a and b.
+func widen(a, b inferredType) inferredType {
+	switch {
+	case a == inferNone || a == b:
+		return b
+	case b == inferNone:
+		return a
+	case (a == inferInteger && b == inferReal) || (a == inferReal && b == inferInteger):
+		return inferReal
+	case (a == inferDate && b == inferTimestamp) || (a == inferTimestamp && b == inferDate):
+		return inferTimestamp
+	}
+
+	return inferText
+}
+
+func (inferred inferredType) typeName(driver string) string {
+	names := map[string][]string{
+		"sqlite3":   {"INTEGER", "REAL", "TEXT", "TEXT", "TEXT"},
+		"postgres":  {"bigint", "double precision", "date", "timestamp", "text"},
+		"mysql":     {"BIGINT", "DOUBLE", "DATE", "DATETIME(6)", "TEXT"},
+		"sqlserver": {"BIGINT", "FLOAT", "DATE", "DATETIME2", "NVARCHAR(MAX)"},
+	}
+
+	dialect, ok := names[driver]
+	if !ok {
+		dialect = names["sqlite3"]
+	}
+
+	switch inferred {
+	case inferInteger:
+		return dialect[0]
+	case inferReal:
+		return dialect[1]
+	case inferDate:
+		return dialect[2]
+	case inferTimestamp:
+		return dialect[3]
+	}
+
+	return dialect[4]
+}
+
+// InferColumns picks a type for each column from the first rows of data,
+// NULLs and empty fields are skipped and a column with nothing else is text.
+func InferColumns(data *ImportData, driver string) []Column {
+	types := inferTypes(data)
+	columns := make([]Column, len(data.Columns))
+	for i, v := range data.Columns {
+		columns[i] = Column{Name: v, Type: types[i].typeName(driver)}
+	}
+
+	return columns
+}
+
+func inferTypes(data *ImportData) []inferredType {
+	types := make([]inferredType, len(data.Columns))
+	for i, row := range data.Rows {
+		if i == inferSample {
+			break
+		}
+
+		for j, v := range row {
+			if v != nil && *v != "" {
+				types[j] = widen(types[j], inferValue(*v))
+			}
+		}
+	}
+
+	return types
+}
+
+// textType reports whether a column of type typ holds text, going by the
+// words that give a Sqlite column text affinity, which also cover the other
+// databases' character types.
+func textType(typ string) bool {
+	typ = strings.ToLower(typ)
+	return strings.Contains(typ, "char") || strings.Contains(typ, "text") || strings.Contains(typ, "clob")
+}
+
+// ColumnMap maps the field at Index, named Field in the header, to Column in
+// the table being imported into. Empty fields go into a Text column as an
+// empty string and are NULL in any other column.
+type ColumnMap struct {
+	Field  string
+	Index  int
+	Column string
+	Text   bool
+}
+
+// ImportPlan is where an import's rows go. Create holds the columns of a new
+// table to create, it's nil when appending to an existing one.
+type ImportPlan struct {
+	Table   string
+	Create  []Column
+	Mapping []ColumnMap
+}
+
+// NewTablePlan creates table with the columns of data and types inferred for
+// driver.
+func NewTablePlan(data *ImportData, table, driver string) (ImportPlan, error) {
+	table = strings.TrimSpace(table)
+	if table == "" {
+		return ImportPlan{}, ErrImportTable
+	}
+
+	plan := ImportPlan{
+		Table:  table,
+		Create: InferColumns(data, driver),
+	}
+	for i, v := range inferTypes(data) {
+		plan.Mapping = append(plan.Mapping, ColumnMap{
+			Field:  data.Columns[i],
+			Index:  i,
+			Column: data.Columns[i],
+			Text:   v == inferText || v == inferNone,
+		})
+	}
+
+	return plan, nil
+}
+
+// AutoMapping picks the field of data for each column of table, the one
+// with the same name ignoring case or -1 if there isn't one.
+func AutoMapping(data *ImportData, table Table) []int {
+	fields := make([]int, len(table.Columns))
+	for i, col := range table.Columns {
+		fields[i] = slices.IndexFunc(data.Columns, func(name string) bool {
+			return strings.EqualFold(name, col.Name)
+		})
+	}
+
+	return fields
+}
+
+// AppendPlan appends the rows of data to table. fields holds the index of
+// the field that goes into each of table's columns, columns with -1 are left
+// out of the INSERT.
+func AppendPlan(data *ImportData, table Table, fields []int) (ImportPlan, error) {
+	plan := ImportPlan{Table: table.Name}
+	for i, index := range fields {
+		if index < 0 || i >= len(table.Columns) {
+			continue
+		}
+		if index >= len(data.Columns) {
+			return ImportPlan{}, fmt.Errorf("the file has no field %d", index+1)
+		}
+
+		col := table.Columns[i]
+		plan.Mapping = append(plan.Mapping, ColumnMap{Field: data.Columns[index], Index: index, Column: col.Name, Text: textType(col.Type)})
+	}
+
+	if len(plan.Mapping) == 0 {
+		return ImportPlan{}, ErrImportNoColumns
+	}
+
+	return plan, nil
+}
+
+// Import runs plan against database inside a transaction, rows are inserted
+// in batches and progress is called with the number of rows written after
+// each one. Values are sent as text literals and the database converts them
+// to the column types. MySQL commits on CREATE TABLE so there the new table
+// is created before the transaction starts and is left behind if the rows
+// fail.
+func Import(ctx context.Context, database Database, data *ImportData, plan ImportPlan, progress func(done, total int)) error {
+	if database.Transaction().Open {
+		return ErrImportTransaction
+	}
+	if len(plan.Mapping) == 0 {
+		return ErrImportNoColumns
+	}
+
+	driver, _ := database.Info()
+	m := &migration{driver: driver}
+
+	var create string
+	if plan.Create != nil {
+		m.createTable(plan.Table, &Table{Columns: plan.Create}, false)
+		create = m.lines[0]
+	}
+
+	if create != "" && driver == "mysql" {
+		_, err := database.Exec(ctx, create)
+		if err != nil {
+			return err
+		}
+		create = ""
+	}
+
+	err := database.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = importRows(ctx, database, m, create, data, plan, progress)
+	if err != nil {
+		database.Rollback()
+		return err
+	}
+
+	return database.Commit()
+}
+
+func importRows(ctx context.Context, database Database, m *migration, create string, data *ImportData, plan ImportPlan, progress func(done, total int)) error {
+	if create != "" {
+		_, err := database.Exec(ctx, create)
+		if err != nil {
+			return err
+		}
+	}
+
+	columns := make([]string, len(plan.Mapping))
+	for i, v := range plan.Mapping {
+		columns[i] = v.Column
+	}
+
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", m.table(plan.Table), m.quoteAll(columns))
+	values := make([]string, len(plan.Mapping))
+	for start := 0; start < len(data.Rows); start += importBatchSize {
+		end := min(start+importBatchSize, len(data.Rows))
+
+		var tuples []string
+		for _, row := range data.Rows[start:end] {
+			for i, v := range plan.Mapping {
+				values[i] = "NULL"
+				if v.Index < len(row) && row[v.Index] != nil && (*row[v.Index] != "" || v.Text) {
+					values[i] = m.text(*row[v.Index])
+				}
+			}
+			tuples = append(tuples, "("+strings.Join(values, ", ")+")")
+		}
+
+		_, err := database.Exec(ctx, prefix+strings.Join(tuples, ",\n"))
+		if err != nil {
+			return fmt.Errorf("rows %d-%d: %w", start+1, end, err)
+		}
+
+		if progress != nil {
+			progress(end, len(data.Rows))
+		}
+	}
+
+	return nil
+}
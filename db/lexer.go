@@ -0,0 +1,250 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+type TokenType byte
+
+const (
+	WordToken TokenType = iota
+	QuotedIdentToken
+	StringToken
+	NumberToken
+	ParamToken
+	CommentToken
+	PunctToken
+)
+
+type StatementKind byte
+
+const (
+	OtherStatement StatementKind = iota
+	RowReturning
+	DDL
+	DML
+	TransactionControl
+)
+
+// Token is a single lexeme from a SQL statement, Start and End are rune
+// offsets into the source so the original text can be sliced back out.
+type Token struct {
+	Type       TokenType
+	Text       string
+	Start, End int
+}
+
+// Upper returns the token text in upper case, used for matching keywords.
+func (tok Token) Upper() string {
+	return strings.ToUpper(tok.Text)
+}
+
+func (tok Token) isWord(words ...string) bool {
+	if tok.Type != WordToken {
+		return false
+	}
+
+	upper := tok.Upper()
+	for _, v := range words {
+		if upper == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (tok Token) isPunct(text string) bool {
+	return tok.Type == PunctToken && tok.Text == text
+}
+
+// Tokenize breaks src up into tokens, whitespace is dropped but comments are
+// kept so callers can decide whether they matter. Strings and quoted
+// identifiers keep their quotes, an unterminated one runs to the end of src.
+//
+// Square brackets only quote identifiers for Sqlite and SQL Server, they're
+// array subscripts in Postgres. ? is a placeholder everywhere but Postgres
+// where ?, ?| and ?& are jsonb operators. An empty driver accepts both.
+// Sqlite also numbers placeholders as ?NNN and names them with $name.
+// MySQL strings can also escape a quote with a backslash.
+func Tokenize(driver, src string) []Token {
+	runes := []rune(src)
+	brackets := driver != "postgres" && driver != "mysql"
+	backslash := driver == "mysql"
+
+	var tokens []Token
+	for i := 0; i < len(runes); {
+		ch := runes[i]
+		start := i
+		tokType := PunctToken
+
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+			continue
+		case ch == '-' && peek(runes, i+1) == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			tokType = CommentToken
+		case ch == '/' && peek(runes, i+1) == '*':
+			i = skipPast(runes, i+2, []rune("*/"))
+			tokType = CommentToken
+		case ch == '\'' && backslash:
+			i = skipEscaped(runes, i, '\'')
+			tokType = StringToken
+		case ch == '\'':
+			i = skipQuoted(runes, i, '\'', '\'')
+			tokType = StringToken
+		case ch == '"' && backslash:
+			i = skipEscaped(runes, i, '"')
+			tokType = QuotedIdentToken
+		case ch == '"' || ch == '`':
+			i = skipQuoted(runes, i, ch, ch)
+			tokType = QuotedIdentToken
+		case ch == '[' && brackets:
+			i = skipQuoted(runes, i, '[', ']')
+			tokType = QuotedIdentToken
+		case ch == '$' && unicode.IsDigit(peek(runes, i+1)):
+			for i++; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+			}
+			tokType = ParamToken
+		case ch == '$' && driver == "sqlite3" && isIdentStart(peek(runes, i+1)):
+			for i++; i < len(runes) && isIdentRune(runes[i]); i++ {
+			}
+			tokType = ParamToken
+		case ch == '$':
+			if tag, ok := dollarTag(runes, i); ok {
+				i = skipPast(runes, i+len(tag), tag)
+				tokType = StringToken
+			} else {
+				i++
+			}
+		case ch == '?' && driver == "postgres":
+			i++
+			if peek(runes, i) == '|' || peek(runes, i) == '&' {
+				i++
+			}
+		case ch == '?' && driver == "sqlite3":
+			for i++; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+			}
+			tokType = ParamToken
+		case ch == '?':
+			i++
+			tokType = ParamToken
+		case ch == ':' && peek(runes, i+1) == ':':
+			i += 2
+		case (ch == ':' || ch == '@') && isIdentStart(peek(runes, i+1)):
+			for i++; i < len(runes) && isIdentRune(runes[i]); i++ {
+			}
+			tokType = ParamToken
+		case unicode.IsDigit(ch) || (ch == '.' && unicode.IsDigit(peek(runes, i+1))):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E') {
+				i++
+			}
+			tokType = NumberToken
+		case isIdentStart(ch):
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			tokType = WordToken
+		default:
+			i++
+		}
+
+		tokens = append(tokens, Token{
+			Type:  tokType,
+			Text:  string(runes[start:i]),
+			Start: start,
+			End:   i,
+		})
+	}
+
+	return tokens
+}
+
+// ClassifyStatement works out what kind of statement stmt is from its
+// leading keywords. Anything that hands back rows, including DML with a
+// RETURNING clause, is RowReturning so it can be run with Query.
+func ClassifyStatement(driver, stmt string) StatementKind {
+	var tokens []Token
+	for _, v := range Tokenize(driver, stmt) {
+		if v.Type != CommentToken {
+			tokens = append(tokens, v)
+		}
+	}
+
+	for len(tokens) > 0 && tokens[0].isPunct("(") {
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) == 0 || tokens[0].Type != WordToken {
+		return OtherStatement
+	}
+
+	first := tokens[0]
+	switch {
+	case first.isWord("WITH"):
+		return classifyWith(tokens[1:])
+	case first.isWord("SELECT", "VALUES", "TABLE", "SHOW", "EXPLAIN", "DESCRIBE", "DESC", "EXEC", "EXECUTE", "CALL"):
+		return RowReturning
+	case first.isWord("PRAGMA"):
+		for _, v := range tokens {
+			if v.isPunct("=") {
+				return OtherStatement
+			}
+		}
+		return RowReturning
+	case first.isWord("INSERT", "UPDATE", "DELETE", "REPLACE", "MERGE", "UPSERT"):
+		if hasTopLevelWord(tokens, "RETURNING", "OUTPUT") {
+			return RowReturning
+		}
+		return DML
+	case first.isWord("CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT"):
+		return DDL
+	case first.isWord("BEGIN", "START", "COMMIT", "ROLLBACK", "SAVEPOINT", "RELEASE", "END", "ABORT"):
+		return TransactionControl
+	}
+
+	return OtherStatement
+}
+
+// classifyWith skips the common table expressions after a WITH and
+// classifies the statement they're attached to.
+func classifyWith(tokens []Token) StatementKind {
+	depth := 0
+	for i, v := range tokens {
+		switch {
+		case v.isPunct("("):
+			depth++
+		case v.isPunct(")"):
+			depth--
+		case depth == 0 && v.isWord("SELECT", "VALUES", "TABLE"):
+			return RowReturning
+		case depth == 0 && v.isWord("INSERT", "UPDATE", "DELETE", "MERGE"):
+			if hasTopLevelWord(tokens[i:], "RETURNING", "OUTPUT") {
+				return RowReturning
+			}
+			return DML
+		}
+	}
+
+	return OtherStatement
+}
+
+func hasTopLevelWord(tokens []Token, words ...string) bool {
+	depth := 0
+	for _, v := range tokens {
+		switch {
+		case v.isPunct("("):
+			depth++
+		case v.isPunct(")"):
+			depth--
+		case depth == 0 && v.isWord(words...):
+			return true
+		}
+	}
+
+	return false
+}
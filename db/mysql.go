@@ -1 +1,316 @@
 package db
+
+import (
+	"context"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+type MySQL struct {
+	db              *sqlx.DB
+	session         *session
+	connStr         string
+	driver          string
+	tableDataFunc   ResultFunc
+	updateViewFunc  UpdateViewFunc
+	continueOnError bool
+	historyFunc     HistoryFunc
+}
+
+func init() {
+	RegisterDriver(Driver{
+		Name:            "mysql",
+		Label:           "MySQL",
+		ConnStrTemplate: "user:password@tcp(localhost:3306)/dbname",
+		Create: func(connStr string, tableFunc ResultFunc, updateViewFunc UpdateViewFunc) (Database, error) {
+			return CreateMySQL(connStr, tableFunc, updateViewFunc)
+		},
+	})
+}
+
+func CreateMySQL(connStr string, tableFunc ResultFunc, updateViewFunc UpdateViewFunc) (*MySQL, error) {
+	mysql := &MySQL{
+		driver:         "mysql",
+		connStr:        connStr,
+		tableDataFunc:  tableFunc,
+		updateViewFunc: updateViewFunc,
+	}
+
+	var err error
+	mysql.db, err = sqlx.Connect(mysql.driver, mysql.connStr)
+	if err == nil {
+		mysql.session = newSession(mysql.db, "START TRANSACTION")
+	}
+
+	return mysql, err
+}
+
+func (mysql *MySQL) Info() (string, string) {
+	return mysql.driver, mysql.connStr
+}
+
+func (mysql *MySQL) Initialize(connStr string) error {
+	mysql.driver = "mysql"
+	mysql.connStr = connStr
+
+	var err error
+	mysql.db, err = sqlx.Connect(mysql.driver, mysql.connStr)
+	if err == nil {
+		mysql.session = newSession(mysql.db, "START TRANSACTION")
+	}
+
+	return err
+}
+
+func (mysql *MySQL) GetDatabases() ([]DbInfo, error) {
+	rows, err := mysql.db.Query("SHOW DATABASES;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dbs []DbInfo
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+
+		dbs = append(dbs, DbInfo{Name: name})
+	}
+
+	return dbs, rows.Err()
+}
+
+func (mysql *MySQL) GetSchemas() ([]SchemaInfo, error) {
+	return nil, ErrNotSupported
+}
+
+func (mysql *MySQL) GetTables() ([]Table, error) {
+	var tableData []TableData
+	err := mysql.session.Select(&tableData, `
+		SELECT
+			c.TABLE_NAME AS TableName,
+			CASE WHEN t.TABLE_TYPE = 'VIEW' THEN 'VIEW' ELSE 'TABLE' END AS TableType,
+			c.COLUMN_NAME AS ColumnName,
+			c.COLUMN_TYPE AS Type,
+			c.IS_NULLABLE = 'NO' AS NotNull,
+			c.COLUMN_DEFAULT AS DefaultValue,
+			c.COLUMN_KEY = 'PRI' AS PrimaryKey
+		FROM
+			information_schema.COLUMNS c
+		INNER JOIN
+			information_schema.TABLES t
+		ON
+			t.TABLE_SCHEMA = c.TABLE_SCHEMA
+			AND t.TABLE_NAME = c.TABLE_NAME
+			AND t.TABLE_TYPE IN ('BASE TABLE', 'VIEW')
+		WHERE
+			c.TABLE_SCHEMA = DATABASE()
+		ORDER BY
+			c.TABLE_NAME,
+			c.ORDINAL_POSITION;
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexData []IndexData
+	err = mysql.session.Select(&indexData, `
+		SELECT
+			TABLE_NAME AS TableName,
+			INDEX_NAME AS IndexName,
+			NON_UNIQUE = 0 AS 'Unique',
+			false AS 'Partial',
+			SEQ_IN_INDEX - 1 AS SeqNo,
+			COALESCE(COLUMN_NAME, '') AS ColumnName
+		FROM
+			information_schema.STATISTICS
+		WHERE
+			TABLE_SCHEMA = DATABASE()
+		ORDER BY
+			TABLE_NAME,
+			INDEX_NAME,
+			SEQ_IN_INDEX;
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := groupTableData(tableData, indexData)
+
+	var fkData []ForeignKeyData
+	err = mysql.session.Select(&fkData, `
+		SELECT
+			kcu.TABLE_NAME AS TableName,
+			kcu.CONSTRAINT_NAME AS ID,
+			kcu.ORDINAL_POSITION - 1 AS Seq,
+			kcu.COLUMN_NAME AS ColumnName,
+			kcu.REFERENCED_TABLE_NAME AS RefTable,
+			kcu.REFERENCED_COLUMN_NAME AS RefColumn,
+			rc.UPDATE_RULE AS OnUpdate,
+			rc.DELETE_RULE AS OnDelete
+		FROM
+			information_schema.KEY_COLUMN_USAGE kcu
+		INNER JOIN
+			information_schema.REFERENTIAL_CONSTRAINTS rc
+		ON
+			rc.CONSTRAINT_SCHEMA = kcu.CONSTRAINT_SCHEMA
+			AND rc.TABLE_NAME = kcu.TABLE_NAME
+			AND rc.CONSTRAINT_NAME = kcu.CONSTRAINT_NAME
+		WHERE
+			kcu.TABLE_SCHEMA = DATABASE()
+			AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY
+			kcu.TABLE_NAME,
+			kcu.CONSTRAINT_NAME,
+			kcu.ORDINAL_POSITION;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	attachForeignKeys(tables, fkData)
+
+	var defs []ObjectDefinition
+	err = mysql.session.Select(&defs, `
+		SELECT
+			TABLE_NAME AS Name,
+			CONCAT('CREATE VIEW ', TABLE_NAME, ' AS ', VIEW_DEFINITION) AS Definition
+		FROM
+			information_schema.VIEWS
+		WHERE
+			TABLE_SCHEMA = DATABASE();
+	`)
+	if err != nil {
+		return nil, err
+	}
+	setDefinitions(tables, defs)
+
+	err = mysql.showCreateTables(tables)
+	if err != nil {
+		return nil, err
+	}
+	fillDefinitions(tables, mysql.driver)
+
+	triggers, err := mysql.GetTriggers()
+	if err != nil {
+		return nil, err
+	}
+	attachTriggers(tables, triggers)
+
+	return tables, nil
+}
+
+// showCreateTables sets each table's definition from SHOW CREATE TABLE,
+// information_schema leaves out AUTO_INCREMENT and ON UPDATE and MySQL 8
+// doesn't quote string defaults there so the DDL can't be rebuilt from it.
+func (mysql *MySQL) showCreateTables(tables []Table) error {
+	m := &migration{driver: mysql.driver}
+	for i, v := range tables {
+		if v.Type != TableObject {
+			continue
+		}
+
+		var defs []struct {
+			Table      string `db:"Table"`
+			Definition string `db:"Create Table"`
+		}
+		err := mysql.session.Select(&defs, "SHOW CREATE TABLE "+m.table(v.Name))
+		if err != nil {
+			return err
+		}
+
+		if len(defs) > 0 {
+			tables[i].Definition = defs[0].Definition
+		}
+	}
+
+	return nil
+}
+
+func (mysql *MySQL) GetTriggers() ([]Trigger, error) {
+	var triggers []Trigger
+	err := mysql.session.Select(&triggers, `
+		SELECT
+			TRIGGER_NAME AS Name,
+			EVENT_OBJECT_TABLE AS TableName,
+			CONCAT(
+				'CREATE TRIGGER ', TRIGGER_NAME, ' ', ACTION_TIMING, ' ', EVENT_MANIPULATION,
+				' ON ', EVENT_OBJECT_TABLE, ' FOR EACH ', ACTION_ORIENTATION, ' ', ACTION_STATEMENT
+			) AS Definition
+		FROM
+			information_schema.TRIGGERS
+		WHERE
+			TRIGGER_SCHEMA = DATABASE()
+		ORDER BY
+			TRIGGER_NAME;
+	`)
+
+	return triggers, err
+}
+
+func (mysql *MySQL) GetRoles() ([]RoleInfo, error) {
+	var roles []RoleInfo
+	err := mysql.session.Select(&roles, `
+		SELECT DISTINCT
+			GRANTEE AS Name
+		FROM
+			information_schema.USER_PRIVILEGES
+		ORDER BY
+			GRANTEE;
+	`)
+
+	return roles, err
+}
+
+func (mysql *MySQL) Select(ctx context.Context, cmd string, args ...any) (*ResultSet, error) {
+	return mysql.session.Result(ctx, cmd, args...)
+}
+
+func (mysql *MySQL) Exec(ctx context.Context, cmd string, args ...any) (int64, error) {
+	result, err := mysql.session.Exec(ctx, cmd, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (mysql *MySQL) GetExecSQLFunc() ExecSQLFunc {
+	return createExecSQLFunc(mysql, mysql.tableDataFunc, mysql.updateViewFunc, &mysql.continueOnError, &mysql.historyFunc)
+}
+
+func (mysql *MySQL) SetContinueOnError(continueOnError bool) {
+	mysql.continueOnError = continueOnError
+}
+
+func (mysql *MySQL) SetHistoryFunc(historyFunc HistoryFunc) {
+	mysql.historyFunc = historyFunc
+}
+
+func (mysql *MySQL) Begin() error {
+	return mysql.session.Begin()
+}
+
+func (mysql *MySQL) Commit() error {
+	return mysql.session.Commit()
+}
+
+func (mysql *MySQL) Rollback() error {
+	return mysql.session.Rollback()
+}
+
+func (mysql *MySQL) SetAutocommit(autocommit bool) {
+	mysql.session.SetAutocommit(autocommit)
+}
+
+func (mysql *MySQL) Transaction() TxStatus {
+	return mysql.session.Status()
+}
+
+func (mysql *MySQL) Close() error {
+	mysql.session.Rollback()
+	return mysql.db.Close()
+}
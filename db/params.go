@@ -0,0 +1,190 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Binds holds the values for a script's bind parameters by the names from
+// ScriptParams, a nil value binds NULL.
+type Binds map[string]*string
+
+// param is a placeholder in a statement, name is its key in Binds. Named
+// placeholders are bound with sql.Named as bind, Postgres' $N and every
+// Sqlite placeholder are bound by their index, everything else is bound in
+// the order it appears.
+type param struct {
+	name  string
+	bind  string
+	index int
+}
+
+// ScriptParams lists the bind parameters in script in the order they first
+// appear, using the placeholder style of the database's driver. The script is
+// split the same way RunScript splits it, ? placeholders are numbered
+// through the whole script as ?1, ?2 and so on while $1, ?3 or :name keep
+// their text, so a name used in several statements takes the same value in
+// each. Like Sqlite, a ? after ?3 is numbered from 4.
+func ScriptParams(database Database, script string) []string {
+	driver, _ := database.Info()
+
+	var names []string
+	seen := make(map[string]bool)
+	next := 0
+	for _, stmt := range splitScript(database, script) {
+		for _, v := range statementParams(driver, stmt, &next) {
+			if !seen[v.name] {
+				seen[v.name] = true
+				names = append(names, v.name)
+			}
+		}
+	}
+
+	return names
+}
+
+// statementParams finds the placeholders in stmt, each named one is only
+// listed once. next counts the ? placeholders seen so far in the script.
+//
+// Sqlite takes ?, ?NNN, :name, @name and $name, Postgres $N and MySQL ?. SQL
+// Server takes @name ignoring case, leaving out @@ functions, variables from
+// a DECLARE in the same batch and the parameters of a procedure, function or
+// trigger being defined.
+func statementParams(driver, stmt string, next *int) []param {
+	tokens := Tokenize(driver, stmt)
+	if driver == "sqlserver" && definesRoutine(tokens) {
+		return nil
+	}
+
+	declared := declaredVariables(tokens)
+	seen := make(map[string]bool)
+
+	// largest is the highest Sqlite parameter index so far, a ? or a new
+	// name takes the one after it.
+	largest := 0
+
+	var params []param
+	for i, tok := range tokens {
+		key := tok.Text
+		if driver == "sqlserver" {
+			key = strings.ToLower(key)
+		}
+		if tok.Type != ParamToken || seen[key] {
+			continue
+		}
+
+		var p param
+		switch {
+		case tok.Text == "?" && driver == "sqlite3":
+			*next++
+			largest++
+			params = append(params, param{name: fmt.Sprintf("?%d", *next), index: largest})
+			continue
+		case tok.Text[0] == '?' && driver == "sqlite3":
+			index, err := strconv.Atoi(tok.Text[1:])
+			if err != nil || index == 0 {
+				continue
+			}
+			largest = max(largest, index)
+			*next = max(*next, index)
+			p = param{name: tok.Text, index: index}
+		case tok.Text == "?" && driver == "mysql":
+			*next++
+			params = append(params, param{name: fmt.Sprintf("?%d", *next)})
+			continue
+		case tok.Text[0] == '$' && driver == "postgres":
+			index, err := strconv.Atoi(tok.Text[1:])
+			if err != nil || index == 0 {
+				continue
+			}
+			p = param{name: tok.Text, index: index}
+		case driver == "sqlite3":
+			largest++
+			p = param{name: tok.Text, index: largest}
+		case tok.Text[0] == '@' && driver == "sqlserver":
+			if declared[strings.ToLower(tok.Text)] || (i > 0 && tokens[i-1].isPunct("@") && tokens[i-1].End == tok.Start) {
+				continue
+			}
+			p = param{name: tok.Text, bind: tok.Text[1:]}
+		default:
+			continue
+		}
+
+		seen[key] = true
+		params = append(params, p)
+	}
+
+	return params
+}
+
+// bindArgs builds the arguments for a statement's params from binds, a name
+// that isn't in binds is bound as NULL.
+func bindArgs(params []param, binds Binds) []any {
+	var args []any
+	for _, v := range params {
+		var value any
+		if s := binds[v.name]; s != nil {
+			value = *s
+		}
+
+		switch {
+		case v.bind != "":
+			args = append(args, sql.Named(v.bind, value))
+		case v.index > 0:
+			for len(args) < v.index {
+				args = append(args, nil)
+			}
+			args[v.index-1] = value
+		default:
+			args = append(args, value)
+		}
+	}
+
+	return args
+}
+
+// declaredVariables finds the T-SQL variables named in DECLARE statements,
+// lower cased since their names aren't case sensitive.
+func declaredVariables(tokens []Token) map[string]bool {
+	declared := make(map[string]bool)
+	inDeclare := false
+	for i, tok := range tokens {
+		switch {
+		case tok.isWord("DECLARE"):
+			inDeclare = true
+		case tok.isPunct(";") || tok.isWord("SELECT", "SET", "INSERT", "UPDATE", "DELETE", "MERGE", "EXEC", "EXECUTE", "IF", "WHILE", "BEGIN", "PRINT", "RETURN", "WITH"):
+			inDeclare = false
+		case inDeclare && tok.Type == ParamToken && i > 0 && (tokens[i-1].isWord("DECLARE") || tokens[i-1].isPunct(",")):
+			declared[strings.ToLower(tok.Text)] = true
+		}
+	}
+
+	return declared
+}
+
+// definesRoutine reports whether the statement creates or alters a
+// procedure, function or trigger.
+func definesRoutine(tokens []Token) bool {
+	var words []Token
+	for _, v := range tokens {
+		if v.Type == CommentToken {
+			continue
+		}
+		if len(words) == 4 {
+			break
+		}
+		words = append(words, v)
+	}
+
+	if len(words) < 2 || !words[0].isWord("CREATE", "ALTER") {
+		return false
+	}
+
+	if words[1].isWord("OR") && len(words) == 4 {
+		words = words[2:]
+	}
+
+	return words[1].isWord("PROCEDURE", "PROC", "FUNCTION", "TRIGGER")
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrExplainStatement = errors.New("select a single statement to explain")
+
+	sqliteIndexRegex = regexp.MustCompile(`USING (?:COVERING |AUTOMATIC (?:COVERING |PARTIAL )?)?INDEX (\S+)|USING (INTEGER PRIMARY KEY|PRIMARY KEY)`)
+)
+
+// Explainer can be implemented by a Database that can show the plan for a
+// statement without running it.
+type Explainer interface {
+	Explain(ctx context.Context, stmt string) (*PlanNode, error)
+}
+
+// PlanNode is one step of a query plan. FullScan is set for steps that read
+// every row of a table, Index is the index the step uses if any. Cost and
+// Rows are the planner's estimates and are only set when the database
+// reports them.
+type PlanNode struct {
+	Label     string
+	Relation  string
+	Index     string
+	FullScan  bool
+	StartCost float64
+	Cost      float64
+	Rows      float64
+	HasCost   bool
+	Children  []*PlanNode
+}
+
+// Summary is the text shown for the node in the plan tree.
+func (node *PlanNode) Summary() string {
+	var parts []string
+	if node.Index != "" && !strings.Contains(node.Label, node.Index) {
+		parts = append(parts, "index "+node.Index)
+	}
+
+	if node.HasCost {
+		parts = append(parts, fmt.Sprintf("cost=%.2f..%.2f rows=%.0f", node.StartCost, node.Cost, node.Rows))
+	}
+
+	if node.FullScan {
+		parts = append(parts, "FULL SCAN")
+	}
+
+	if len(parts) == 0 {
+		return node.Label
+	}
+
+	return fmt.Sprintf("%s (%s)", node.Label, strings.Join(parts, ", "))
+}
+
+// explainStatement trims stmt down to the one statement being explained.
+func explainStatement(driver, stmt string) (string, error) {
+	stmts := SplitStatements(driver, stmt)
+	if len(stmts) != 1 {
+		return "", ErrExplainStatement
+	}
+
+	return stmts[0], nil
+}
+
+// sqlitePlan builds the plan tree from the rows of EXPLAIN QUERY PLAN, each
+// row has the id of its parent with 0 being the root.
+func sqlitePlan(rows *sql.Rows) (*PlanNode, error) {
+	defer rows.Close()
+
+	root := &PlanNode{Label: "QUERY PLAN"}
+	nodes := map[int]*PlanNode{0: root}
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		err := rows.Scan(&id, &parent, &notUsed, &detail)
+		if err != nil {
+			return nil, err
+		}
+
+		node := &PlanNode{Label: detail}
+		fields := strings.Fields(detail)
+		if len(fields) > 1 && (fields[0] == "SCAN" || fields[0] == "SEARCH") {
+			node.Relation = fields[1]
+			if node.Relation == "TABLE" && len(fields) > 2 {
+				node.Relation = fields[2]
+			}
+		}
+
+		if match := sqliteIndexRegex.FindStringSubmatch(detail); match != nil {
+			node.Index = match[1] + match[2]
+		}
+
+		node.FullScan = len(fields) > 1 && fields[0] == "SCAN" && node.Index == "" && fields[1] != "CONSTANT"
+
+		owner, ok := nodes[parent]
+		if !ok {
+			owner = root
+		}
+		owner.Children = append(owner.Children, node)
+		nodes[id] = node
+	}
+
+	return root, rows.Err()
+}
+
+type pgPlan struct {
+	NodeType     string   `json:"Node Type"`
+	RelationName string   `json:"Relation Name"`
+	Alias        string   `json:"Alias"`
+	IndexName    string   `json:"Index Name"`
+	JoinType     string   `json:"Join Type"`
+	StartupCost  float64  `json:"Startup Cost"`
+	TotalCost    float64  `json:"Total Cost"`
+	PlanRows     float64  `json:"Plan Rows"`
+	Plans        []pgPlan `json:"Plans"`
+}
+
+// postgresPlan builds the plan tree from the output of EXPLAIN (FORMAT
+// JSON), which is a single value holding the nested plan.
+func postgresPlan(data []byte) (*PlanNode, error) {
+	var plans []struct {
+		Plan pgPlan `json:"Plan"`
+	}
+
+	err := json.Unmarshal(data, &plans)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &PlanNode{Label: "QUERY PLAN"}
+	for _, v := range plans {
+		root.Children = append(root.Children, v.Plan.node())
+	}
+
+	return root, nil
+}
+
+func (plan pgPlan) node() *PlanNode {
+	label := plan.NodeType
+	if plan.JoinType != "" && strings.HasSuffix(plan.NodeType, "Join") {
+		label = fmt.Sprintf("%s %s", plan.JoinType, plan.NodeType)
+	}
+	if plan.IndexName != "" {
+		label += " using " + plan.IndexName
+	}
+	if plan.RelationName != "" {
+		label += " on " + plan.RelationName
+		if plan.Alias != "" && plan.Alias != plan.RelationName {
+			label += " " + plan.Alias
+		}
+	}
+
+	node := &PlanNode{
+		Label:     label,
+		Relation:  plan.RelationName,
+		Index:     plan.IndexName,
+		FullScan:  plan.NodeType == "Seq Scan",
+		StartCost: plan.StartupCost,
+		Cost:      plan.TotalCost,
+		Rows:      plan.PlanRows,
+		HasCost:   true,
+	}
+
+	for _, v := range plan.Plans {
+		node.Children = append(node.Children, v.node())
+	}
+
+	return node
+}
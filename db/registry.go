@@ -0,0 +1,71 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrUnknownDriver = errors.New("unknown database driver")
+
+	drivers = map[string]Driver{}
+)
+
+type UpdateViewFunc func([]Table)
+
+// ResultFunc receives the outcome of a query, rows is nil for statements
+// that don't return any and resultMsg holds their message instead.
+type ResultFunc func(rows *ResultSet, resultMsg []rune)
+
+type DriverFactory func(connStr string, tableFunc ResultFunc, updateViewFunc UpdateViewFunc) (Database, error)
+
+// Driver describes a database backend, Name is the database/sql driver name
+// saved with each connection and Label is what's shown in the new connection
+// form along with ConnStrTemplate as an example connection string.
+type Driver struct {
+	Name            string
+	Label           string
+	ConnStrTemplate string
+	Create          DriverFactory
+}
+
+// RegisterDriver makes a backend available to the rest of sqline, it's meant
+// to be called from the init function of the file implementing the backend.
+func RegisterDriver(driver Driver) {
+	if _, ok := drivers[driver.Name]; ok {
+		panic(fmt.Sprintf("driver %s registered twice", driver.Name))
+	}
+
+	drivers[driver.Name] = driver
+}
+
+// Drivers returns every registered driver sorted by label.
+func Drivers() []Driver {
+	list := make([]Driver, 0, len(drivers))
+	for _, v := range drivers {
+		list = append(list, v)
+	}
+
+	slices.SortFunc(list, func(a, b Driver) int {
+		return strings.Compare(a.Label, b.Label)
+	})
+
+	return list
+}
+
+func GetDriver(name string) (Driver, bool) {
+	driver, ok := drivers[name]
+	return driver, ok
+}
+
+// Open connects to connStr using the driver registered under name.
+func Open(name, connStr string, tableFunc ResultFunc, updateViewFunc UpdateViewFunc) (Database, error) {
+	driver, ok := drivers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, name)
+	}
+
+	return driver.Create(connStr, tableFunc, updateViewFunc)
+}
@@ -0,0 +1,379 @@
+package db
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/sleepy-day/sqline/shared"
+)
+
+const (
+	firstPageSize = 200
+	detachLimit   = 10000
+)
+
+// ResultSet is a cursor over the rows returned by a query, rows are read a
+// page at a time through Next so a large result never has to be held in
+// memory all at once. The first page is read when the ResultSet is created
+// so slow queries do their work on the goroutine that ran them. A cursor
+// over a batch that returns several result sets reads one set at a time,
+// NextSet moves on to the next.
+type ResultSet struct {
+	mu        sync.Mutex
+	rows      *sql.Rows
+	columns   []ColumnInfo
+	buf       []Row
+	fetched   int
+	done      bool
+	truncated bool
+	multi     bool
+	hasNext   bool
+	statement string
+	total     int
+	complete  bool
+	finished  func(count int64)
+}
+
+// newResultSet reads the first page of rows, when multi is set the rows can
+// hold several result sets and any before the first one with columns are
+// skipped.
+func newResultSet(rows *sql.Rows, multi bool) (*ResultSet, error) {
+	colTypes, err := rows.ColumnTypes()
+	for err == nil && multi && len(colTypes) == 0 && rows.NextResultSet() {
+		colTypes, err = rows.ColumnTypes()
+	}
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	rs := &ResultSet{
+		rows:    rows,
+		columns: columnInfo(colTypes),
+		multi:   multi,
+	}
+
+	rs.buf, err = rs.read(firstPageSize)
+	if err != nil {
+		rs.Close()
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+// NewStaticResultSet wraps rows that have already been read.
+func NewStaticResultSet(columns []ColumnInfo, rows []Row) *ResultSet {
+	return &ResultSet{
+		columns:  columns,
+		buf:      rows,
+		done:     true,
+		total:    len(rows),
+		complete: true,
+	}
+}
+
+func (rs *ResultSet) Columns() []ColumnInfo {
+	return rs.columns
+}
+
+// ColumnNames is the name of each column.
+func (rs *ResultSet) ColumnNames() []string {
+	var names []string
+	for _, v := range rs.columns {
+		names = append(names, v.Name)
+	}
+
+	return names
+}
+
+// NextRows is Next for the data table, which only needs to show the rows.
+func (rs *ResultSet) NextRows(n int) ([]shared.Row, error) {
+	rows, err := rs.Next(n)
+
+	page := make([]shared.Row, len(rows))
+	for i, v := range rows {
+		page[i] = v
+	}
+
+	return page, err
+}
+
+// Statement is the statement the rows came from when they were selected by
+// RunScript.
+func (rs *ResultSet) Statement() string {
+	return rs.statement
+}
+
+// Next returns up to n more rows, or every remaining row if n is 0 or less.
+func (rs *ResultSet) Next(n int) ([]Row, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if n <= 0 || n > len(rs.buf) {
+		page := rs.buf
+		rs.buf = nil
+
+		more, err := rs.read(n - len(page))
+		page = append(page, more...)
+		rs.fetched += len(page)
+		return page, err
+	}
+
+	page := rs.buf[:n]
+	rs.buf = rs.buf[n:]
+	rs.fetched += len(page)
+	return page, nil
+}
+
+// Fetched returns how many rows have been handed out by Next so far.
+func (rs *ResultSet) Fetched() int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.fetched
+}
+
+// Count returns how many rows have been read from the cursor so far, done is
+// true once that's all of them. It stays false if the cursor was closed
+// before its last row.
+func (rs *ResultSet) Count() (count int, done bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.fetched + len(rs.buf), rs.complete
+}
+
+// Done reports whether every row has been read from the cursor.
+func (rs *ResultSet) Done() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.done && len(rs.buf) == 0
+}
+
+// HasNextSet reports whether another result set follows this one, it's
+// only known once this one has been read to the end.
+func (rs *ResultSet) HasNextSet() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.hasNext
+}
+
+// NextSet skips whatever is left of this result set and returns the one
+// after it from the same batch, nil if there isn't another. The new set
+// shares the cursor so this one is finished once it's returned.
+func (rs *ResultSet) NextSet() (*ResultSet, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	for !rs.done {
+		if rs.rows.Next() {
+			rs.total++
+			continue
+		}
+
+		if err := rs.endOfSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	rs.buf = nil
+	if !rs.hasNext {
+		return nil, nil
+	}
+
+	rs.hasNext = false
+	colTypes, err := rs.rows.ColumnTypes()
+	if err != nil {
+		rs.rows.Close()
+		return nil, err
+	}
+
+	return &ResultSet{
+		rows:    rs.rows,
+		columns: columnInfo(colTypes),
+		multi:   true,
+	}, nil
+}
+
+// endOfSet finishes the cursor once the rows of the current result set have
+// run out, the rows are kept open when another set with columns follows.
+// The caller holds mu.
+func (rs *ResultSet) endOfSet() error {
+	err := rs.rows.Err()
+	if err == nil && rs.multi {
+		for rs.rows.NextResultSet() {
+			colTypes, err := rs.rows.ColumnTypes()
+			if err == nil && len(colTypes) > 0 {
+				rs.hasNext = true
+				rs.finish(true)
+				return nil
+			}
+		}
+		err = rs.rows.Err()
+	}
+
+	rs.finish(err == nil)
+	rs.rows.Close()
+	return err
+}
+
+// Truncated reports whether the cursor was closed by detach before its last
+// row was read.
+func (rs *ResultSet) Truncated() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.truncated
+}
+
+// detach reads the rest of the rows into the buffer so the transaction's
+// connection can run another statement, more than detachLimit rows or an
+// error while reading them cuts the result short, as does a result set
+// following this one.
+func (rs *ResultSet) detach() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.done && !rs.hasNext {
+		return
+	}
+
+	page, err := rs.read(detachLimit)
+	rs.buf = append(rs.buf, page...)
+	if err != nil || !rs.done || rs.hasNext {
+		rs.truncated = true
+		rs.hasNext = false
+		if !rs.done {
+			rs.finish(false)
+		}
+		rs.rows.Close()
+	}
+}
+
+// Close stops fetching and releases the connection held by the cursor, it's
+// safe to call while another goroutine is waiting on Next.
+func (rs *ResultSet) Close() error {
+	if rs.rows == nil {
+		return nil
+	}
+
+	err := rs.rows.Close()
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if !rs.done {
+		rs.finish(false)
+	}
+	rs.hasNext = false
+	rs.buf = nil
+	return err
+}
+
+// WhenFinished calls fn with how many rows the cursor returned once it's
+// been read to the end, or with -1 if it's closed before that. fn is called
+// straight away if the cursor has already finished, otherwise on its own
+// goroutine.
+func (rs *ResultSet) WhenFinished(fn func(count int64)) {
+	rs.mu.Lock()
+	if !rs.done {
+		rs.finished = fn
+		rs.mu.Unlock()
+		return
+	}
+
+	count := int64(-1)
+	if rs.complete {
+		count = int64(rs.total)
+	}
+	rs.mu.Unlock()
+
+	fn(count)
+}
+
+// finish marks the cursor as done, complete is false when it stopped before
+// its last row. The caller holds mu.
+func (rs *ResultSet) finish(complete bool) {
+	rs.done = true
+	rs.complete = complete
+	if rs.finished == nil {
+		return
+	}
+
+	count := int64(-1)
+	if complete {
+		count = int64(rs.total)
+	}
+	go rs.finished(count)
+	rs.finished = nil
+}
+
+// drain reads and discards the rest of the rows, returning how many there
+// were in total. Used for statements in the middle of a script where only
+// the row count is shown.
+func (rs *ResultSet) drain() (int, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	count := rs.fetched + len(rs.buf)
+	rs.buf = nil
+
+	for !rs.done || rs.hasNext {
+		rs.hasNext = false
+		for rs.rows.Next() {
+			count++
+			rs.total++
+		}
+
+		if err := rs.endOfSet(); err != nil {
+			rs.fetched = count
+			return count, err
+		}
+	}
+
+	rs.fetched = count
+	return count, nil
+}
+
+// read pulls up to n rows off the cursor, n <= 0 reads until the end.
+func (rs *ResultSet) read(n int) ([]Row, error) {
+	var page []Row
+	for !rs.done && (n <= 0 || len(page) < n) {
+		if !rs.rows.Next() {
+			return page, rs.endOfSet()
+		}
+
+		row, err := scanRow(rs.rows, rs.columns)
+		if err != nil {
+			return page, err
+		}
+
+		page = append(page, row)
+		rs.total++
+	}
+
+	return page, nil
+}
+
+func scanRow(rows *sql.Rows, cols []ColumnInfo) (Row, error) {
+	raw := make([]any, len(cols))
+	dest := make([]any, len(cols))
+	for i := range cols {
+		dest[i] = &raw[i]
+	}
+
+	err := rows.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	row := make(Row, len(cols))
+	for i, v := range raw {
+		row[i] = toValue(v, cols[i])
+	}
+
+	return row, nil
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatementResult holds the outcome of one statement from a script, Rows is
+// set for statements that return rows and Result for everything else.
+// Only a script with a single statement keeps Rows open, for longer scripts
+// the rows are counted and the cursor is closed. RowCount is how many rows
+// were returned or changed, it's -1 while Rows still has rows to read and
+// Rows.WhenFinished gives the count once they have been. Duration is
+// how long the statement took from Start, not counting rows left in Rows.
+type StatementResult struct {
+	Statement string
+	Kind      StatementKind
+	Rows      *ResultSet
+	RowCount  int64
+	Result    []rune
+	Err       error
+	Start     time.Time
+	Duration  time.Duration
+}
+
+// Affected is how many rows the statement returned or changed, it's -1 when
+// that isn't known because the statement failed or Rows is still open.
+func (result StatementResult) Affected() int64 {
+	if result.Err != nil {
+		return -1
+	}
+
+	return result.RowCount
+}
+
+// scriptSplitter can be implemented by a Database whose scripts aren't
+// separated by semicolons, such as T-SQL batches separated by GO.
+type scriptSplitter interface {
+	SplitScript(script string) []string
+}
+
+// statementClassifier can be implemented by a Database whose script pieces
+// hold more than one statement and can't be classified by their first word.
+type statementClassifier interface {
+	ClassifyStatement(stmt string) StatementKind
+}
+
+// RunScript splits script into statements and runs them in order against
+// database. It stops at the first statement that fails unless
+// continueOnError is set, the failed statement is included in the results.
+// Cancelling ctx always stops the script. When binds isn't nil each
+// statement's placeholders are bound to the values for the names from
+// ScriptParams.
+func RunScript(ctx context.Context, database Database, script string, continueOnError bool, binds Binds) []StatementResult {
+	stmts := splitScript(database, script)
+	driver, _ := database.Info()
+
+	var results []StatementResult
+	next := 0
+	for _, stmt := range stmts {
+		result := StatementResult{Statement: stmt, Start: time.Now()}
+
+		var args []any
+		if binds != nil {
+			args = bindArgs(statementParams(driver, stmt, &next), binds)
+		}
+
+		if classifier, ok := database.(statementClassifier); ok {
+			result.Kind = classifier.ClassifyStatement(stmt)
+		} else {
+			result.Kind = ClassifyStatement(driver, stmt)
+		}
+
+		if result.Kind == RowReturning {
+			result.Rows, result.Err = database.Select(ctx, stmt, args...)
+			if result.Err == nil {
+				result.Rows.statement = stmt
+				result.RowCount = -1
+				if count, done := result.Rows.Count(); done {
+					result.RowCount = int64(count)
+				}
+			}
+			if result.Err == nil && len(stmts) > 1 {
+				var count int
+				count, result.Err = result.Rows.drain()
+				result.RowCount = int64(count)
+				result.Rows = nil
+			}
+		} else {
+			result.RowCount, result.Err = database.Exec(ctx, stmt, args...)
+			if result.Err == nil {
+				result.Result = []rune(fmt.Sprintf(rowsAffectedFormat, result.RowCount))
+			}
+		}
+		result.Duration = time.Since(result.Start)
+
+		results = append(results, result)
+		if ctx.Err() != nil || (result.Err != nil && !continueOnError) {
+			break
+		}
+	}
+
+	return results
+}
+
+func splitScript(database Database, script string) []string {
+	if splitter, ok := database.(scriptSplitter); ok {
+		return splitter.SplitScript(script)
+	}
+
+	driver, _ := database.Info()
+	return SplitStatements(driver, script)
+}
+
+// scriptSummary builds a table with a row per statement showing whether it
+// succeeded, used when a script has more than one statement.
+func scriptSummary(results []StatementResult) *ResultSet {
+	var rows []Row
+	for i, v := range results {
+		var result string
+		switch {
+		case v.Err != nil:
+			result = fmt.Sprintf("Error: %s", v.Err.Error())
+		case v.Kind == RowReturning:
+			result = fmt.Sprintf("%d rows returned", v.RowCount)
+		default:
+			result = string(v.Result)
+		}
+
+		rows = append(rows, Row{
+			IntVal(int64(i + 1)),
+			TextVal(strings.Join(strings.Fields(v.Statement), " ")),
+			TextVal(result),
+		})
+	}
+
+	return NewStaticResultSet(TextColumns("#", "Statement", "Result"), rows)
+}
@@ -0,0 +1,334 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrNoTransaction   = errors.New("no transaction is open")
+	ErrTransactionOpen = errors.New("a transaction is already open")
+)
+
+type txEffect byte
+
+const (
+	txNone txEffect = iota
+	txBegin
+	txEnd
+)
+
+// TxStatus describes the transaction state of a Database, Statements is how
+// many statements have been run since the transaction was opened.
+type TxStatus struct {
+	Open       bool
+	Statements int
+	Autocommit bool
+}
+
+// queryer is the part of sqlx.DB and sqlx.Conn that statements are run with.
+type queryer interface {
+	sqlx.QueryerContext
+	sqlx.ExecerContext
+}
+
+// session runs the statements for a Database. Outside of a transaction they
+// go straight to the connection pool, once one is opened a single connection
+// is taken from the pool and every statement runs on it until the
+// transaction is committed or rolled back. Transactions are opened with
+// Begin, by running a BEGIN statement from the editor, or implicitly by the
+// next statement when autocommit is off.
+type session struct {
+	mu         sync.Mutex
+	db         *sqlx.DB
+	conn       *sqlx.Conn
+	rows       *sql.Rows
+	result     *ResultSet
+	multi      bool
+	beginStmt  string
+	autocommit bool
+	statements int
+}
+
+func newSession(db *sqlx.DB, beginStmt string) *session {
+	return &session{
+		db:         db,
+		beginStmt:  beginStmt,
+		autocommit: true,
+	}
+}
+
+func (s *session) Query(ctx context.Context, cmd string, args ...any) (*sql.Rows, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.query(ctx, cmd, args...)
+}
+
+// Result runs cmd and reads the first page of its rows. Inside a transaction
+// the ResultSet is kept so the rest of its rows can be read in before the
+// connection runs another statement.
+func (s *session) Result(ctx context.Context, cmd string, args ...any) (*ResultSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows, err := s.query(ctx, cmd, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := newResultSet(rows, s.multi)
+	if err == nil && s.conn != nil && rows == s.rows {
+		s.result = rs
+	}
+
+	return rs, err
+}
+
+func (s *session) query(ctx context.Context, cmd string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := s.run(cmd, func(q queryer) error {
+		var err error
+		rows, err = q.QueryContext(ctx, cmd, args...)
+		return err
+	})
+
+	if s.conn != nil {
+		s.rows = rows
+	}
+
+	return rows, err
+}
+
+func (s *session) Exec(ctx context.Context, cmd string, args ...any) (sql.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result sql.Result
+	err := s.run(cmd, func(q queryer) error {
+		var err error
+		result, err = q.ExecContext(ctx, cmd, args...)
+		return err
+	})
+
+	return result, err
+}
+
+// Select is used for the schema queries, they run inside the transaction if
+// one is open so uncommitted DDL shows up in the trees.
+func (s *session) Select(dest any, query string, args ...any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closeRows()
+	return sqlx.SelectContext(context.Background(), s.queryer(), dest, query, args...)
+}
+
+// run works out whether cmd starts or ends a transaction, takes or releases
+// the transaction's connection to match and runs fn against it.
+func (s *session) run(cmd string, fn func(q queryer) error) error {
+	s.closeRows()
+
+	effect := transactionEffect(s.db.DriverName(), cmd)
+	pinned := false
+	if s.conn == nil && (effect == txBegin || (effect == txNone && !s.autocommit)) {
+		err := s.pin()
+		if err != nil {
+			return err
+		}
+		pinned = true
+
+		if effect == txNone {
+			_, err = s.conn.ExecContext(context.Background(), s.beginStmt)
+			if err != nil {
+				s.release()
+				return err
+			}
+		}
+	}
+
+	inTx := s.conn != nil
+	err := fn(s.queryer())
+	if !inTx {
+		return err
+	}
+
+	switch {
+	case isBadConn(err):
+		s.release()
+		return fmt.Errorf("%w, the transaction was rolled back", err)
+	case effect == txEnd:
+		if err != nil {
+			s.conn.ExecContext(context.Background(), "ROLLBACK")
+		}
+		s.release()
+	case effect == txBegin && err != nil && pinned:
+		s.release()
+	case effect == txBegin:
+		s.statements = 0
+	default:
+		s.statements++
+	}
+
+	return err
+}
+
+func (s *session) Begin() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		return ErrTransactionOpen
+	}
+
+	err := s.pin()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn.ExecContext(context.Background(), s.beginStmt)
+	if err != nil {
+		s.release()
+	}
+
+	return err
+}
+
+func (s *session) Commit() error {
+	return s.end("COMMIT")
+}
+
+func (s *session) Rollback() error {
+	return s.end("ROLLBACK")
+}
+
+// end finishes the open transaction with stmt, if a COMMIT fails the
+// transaction is rolled back so the connection goes back to the pool clean.
+func (s *session) end(stmt string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return ErrNoTransaction
+	}
+
+	s.closeRows()
+	_, err := s.conn.ExecContext(context.Background(), stmt)
+	if err != nil && stmt != "ROLLBACK" {
+		s.conn.ExecContext(context.Background(), "ROLLBACK")
+	}
+
+	s.release()
+	return err
+}
+
+// SetAutocommit changes whether statements open a transaction when one isn't
+// already open, a transaction that's already open is left alone.
+func (s *session) SetAutocommit(autocommit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.autocommit = autocommit
+}
+
+func (s *session) Status() TxStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return TxStatus{
+		Open:       s.conn != nil,
+		Statements: s.statements,
+		Autocommit: s.autocommit,
+	}
+}
+
+func (s *session) queryer() queryer {
+	if s.conn != nil {
+		return s.conn
+	}
+
+	return s.db
+}
+
+func (s *session) pin() error {
+	conn, err := s.db.Connx(context.Background())
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	s.statements = 0
+	return nil
+}
+
+func (s *session) release() {
+	s.closeRows()
+	s.conn.Close()
+	s.conn = nil
+	s.statements = 0
+}
+
+// closeRows closes the last result read inside the transaction, a
+// connection can't start a new statement while it's still sending rows. A
+// result being shown in the data table is read in first rather than lost.
+func (s *session) closeRows() {
+	if s.result != nil {
+		s.result.detach()
+		s.result = nil
+	}
+
+	if s.rows != nil {
+		s.rows.Close()
+		s.rows = nil
+	}
+}
+
+func isBadConn(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone)
+}
+
+// transactionEffect reports whether script opens or closes a transaction
+// from the leading keywords of its statements, the last statement that does
+// either wins. BEGIN is only counted when it's alone or followed by one of
+// the transaction keywords so T-SQL BEGIN ... END blocks are skipped, and
+// ROLLBACK TO a savepoint leaves the transaction open.
+func transactionEffect(driver, script string) txEffect {
+	effect := txNone
+	for _, stmt := range SplitStatements(driver, script) {
+		var tokens []Token
+		for _, v := range Tokenize(driver, stmt) {
+			if v.Type != CommentToken {
+				tokens = append(tokens, v)
+			}
+		}
+
+		if len(tokens) == 0 {
+			continue
+		}
+
+		first := tokens[0]
+		second := Token{Type: PunctToken}
+		if len(tokens) > 1 {
+			second = tokens[1]
+		}
+
+		switch {
+		case first.isWord("BEGIN") && (len(tokens) == 1 || second.isWord("TRAN", "TRANSACTION", "WORK", "DEFERRED", "IMMEDIATE", "EXCLUSIVE", "DISTRIBUTED", "ISOLATION", "READ")):
+			effect = txBegin
+		case first.isWord("START") && second.isWord("TRANSACTION"):
+			effect = txBegin
+		case first.isWord("COMMIT", "ABORT"):
+			effect = txEnd
+		case first.isWord("ROLLBACK") && !hasTopLevelWord(tokens, "TO"):
+			effect = txEnd
+		}
+	}
+
+	return effect
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitStatements breaks a script into its individual statements on
+// semicolons, skipping over any that are inside quoted strings, quoted
+// identifiers, comments, Postgres dollar quoted strings or the BEGIN ... END
+// body of a CREATE TRIGGER. The returned statements are trimmed and don't
+// include the terminating semicolon, empty statements are dropped.
+func SplitStatements(driver, script string) []string {
+	src := []rune(script)
+
+	var stmts []string
+	for _, v := range statementRanges(driver, script) {
+		if stmt := strings.TrimSpace(string(src[v[0]:v[1]])); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	return stmts
+}
+
+// StatementAt returns the statement in script that contains the rune offset,
+// a cursor sitting on or just after a statement's semicolon counts as being
+// in that statement.
+func StatementAt(driver, script string, offset int) string {
+	src := []rune(script)
+
+	ranges := statementRanges(driver, script)
+	for i, v := range ranges {
+		stmt := strings.TrimSpace(string(src[v[0]:v[1]]))
+		end := v[1]
+		if i < len(ranges)-1 {
+			end = ranges[i+1][0]
+		}
+
+		if offset <= end && stmt != "" {
+			return stmt
+		}
+	}
+
+	for i := len(ranges) - 1; i >= 0; i-- {
+		if stmt := strings.TrimSpace(string(src[ranges[i][0]:ranges[i][1]])); stmt != "" {
+			return stmt
+		}
+	}
+
+	return ""
+}
+
+// statementRanges returns the rune offsets of each statement in script, the
+// end offset is the terminating semicolon or the end of the script.
+func statementRanges(driver, script string) [][2]int {
+	var ranges [][2]int
+	start := 0
+	trigger := false
+	depth := 0
+	words := 0
+
+	for _, tok := range Tokenize(driver, script) {
+		switch {
+		case tok.Type == WordToken:
+			words++
+			switch {
+			case words <= 4 && tok.isWord("TRIGGER"):
+				trigger = true
+			case trigger && tok.isWord("BEGIN", "CASE"):
+				depth++
+			case trigger && tok.isWord("END") && depth > 0:
+				depth--
+			}
+		case tok.isPunct(";") && depth == 0:
+			ranges = append(ranges, [2]int{start, tok.Start})
+			start = tok.End
+			trigger = false
+			words = 0
+		}
+	}
+
+	ranges = append(ranges, [2]int{start, len([]rune(script))})
+	return ranges
+}
+
+// skipQuoted returns the index just past the quoted section starting at i,
+// a doubled closing quote is treated as an escaped quote.
+func skipQuoted(src []rune, i int, open, close rune) int {
+	for i++; i < len(src); i++ {
+		if src[i] != close {
+			continue
+		}
+
+		if peek(src, i+1) == close && open == close {
+			i++
+			continue
+		}
+
+		return i + 1
+	}
+
+	return len(src)
+}
+
+// skipEscaped is skipQuoted for MySQL strings where a backslash escapes the
+// character after it as well.
+func skipEscaped(src []rune, i int, quote rune) int {
+	for i++; i < len(src); i++ {
+		switch {
+		case src[i] == '\\':
+			i++
+		case src[i] != quote:
+		case peek(src, i+1) == quote:
+			i++
+		default:
+			return i + 1
+		}
+	}
+
+	return len(src)
+}
+
+// skipPast returns the index just past the next occurrence of end at or
+// after i, or the end of src if there isn't one.
+func skipPast(src []rune, i int, end []rune) int {
+	for ; i+len(end) <= len(src); i++ {
+		if string(src[i:i+len(end)]) == string(end) {
+			return i + len(end)
+		}
+	}
+
+	return len(src)
+}
+
+// dollarTag returns the $tag$ opening a dollar quoted string at i.
+func dollarTag(src []rune, i int) ([]rune, bool) {
+	if i > 0 && isIdentRune(src[i-1]) {
+		return nil, false
+	}
+
+	for j := i + 1; j < len(src); j++ {
+		if src[j] == '$' {
+			return src[i : j+1], true
+		}
+
+		if !isIdentRune(src[j]) || (j == i+1 && unicode.IsDigit(src[j])) {
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+func peek(src []rune, i int) rune {
+	if i < len(src) {
+		return src[i]
+	}
+
+	return 0
+}
+
+func isIdentStart(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch)
+}
+
+func isIdentRune(ch rune) bool {
+	return ch == '_' || ch == '$' || unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
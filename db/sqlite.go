@@ -1,33 +1,47 @@
 package db
 
 import (
-	"fmt"
-	"regexp"
+	"context"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/sleepy-day/sqline/components"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 type Sqlite struct {
-	db             *sqlx.DB
-	connStr        string
-	driver         string
-	tableDataFunc  func([][][]rune, []rune)
-	updateViewFunc func([]Table)
-	selectRegex    *regexp.Regexp
+	db              *sqlx.DB
+	session         *session
+	connStr         string
+	driver          string
+	tableDataFunc   ResultFunc
+	updateViewFunc  UpdateViewFunc
+	continueOnError bool
+	historyFunc     HistoryFunc
 }
 
-func CreateSqlite(connStr string, tableFunc func([][][]rune, []rune), updateViewFunc func([]Table)) (*Sqlite, error) {
+func init() {
+	RegisterDriver(Driver{
+		Name:            "sqlite3",
+		Label:           "Sqlite",
+		ConnStrTemplate: "file:/path/to/database.db",
+		Create: func(connStr string, tableFunc ResultFunc, updateViewFunc UpdateViewFunc) (Database, error) {
+			return CreateSqlite(connStr, tableFunc, updateViewFunc)
+		},
+	})
+}
+
+func CreateSqlite(connStr string, tableFunc ResultFunc, updateViewFunc UpdateViewFunc) (*Sqlite, error) {
 	sqlite := &Sqlite{
 		driver:         "sqlite3",
 		connStr:        connStr,
 		tableDataFunc:  tableFunc,
 		updateViewFunc: updateViewFunc,
-		selectRegex:    selectRegex(),
 	}
 
 	var err error
 	sqlite.db, err = sqlx.Connect(sqlite.driver, sqlite.connStr)
+	if err == nil {
+		sqlite.session = newSession(sqlite.db, "BEGIN")
+	}
 
 	return sqlite, err
 }
@@ -42,6 +56,10 @@ func (lite *Sqlite) Initialize(connStr string) error {
 
 	var err error
 	lite.db, err = sqlx.Connect(lite.driver, lite.connStr)
+	if err == nil {
+		lite.session = newSession(lite.db, "BEGIN")
+	}
+
 	return err
 }
 
@@ -53,25 +71,27 @@ func (lite *Sqlite) GetSchemas() ([]SchemaInfo, error) {
 	return nil, ErrNotSupported
 }
 
+// GetTables reads the tables and views from sqlite_schema, virtual tables
+// are read separately since their columns can only be listed when the
+// module that implements them is loaded.
 func (lite *Sqlite) GetTables() ([]Table, error) {
 	var tableData []TableData
-	err := lite.db.Select(&tableData, `
+	err := lite.session.Select(&tableData, `
 		SELECT
 			ss.name AS TableName,
+			CASE ss.type WHEN 'view' THEN 'VIEW' ELSE 'TABLE' END AS TableType,
 			pti.name AS ColumnName,
 			pti.type AS Type,
 			"notnull" AS "NotNull",
 			dflt_value AS DefaultValue,
-			pk AS PrimaryKey,
-			pfkl."to" AS FKTo,
-			on_update AS OnUpdate,
-			on_delete AS OnDelete
+			pk > 0 AS PrimaryKey
 		FROM
 			sqlite_schema ss
 		INNER JOIN
 			pragma_table_info(ss.name) pti
-		LEFT JOIN
-			pragma_foreign_key_list(ss.name) pfkl
+		WHERE
+			ss.type IN ('table', 'view')
+			AND ss.sql NOT LIKE 'CREATE VIRTUAL TABLE%'
 		ORDER BY
 			ss.name;
 	`)
@@ -80,20 +100,24 @@ func (lite *Sqlite) GetTables() ([]Table, error) {
 	}
 
 	var indexData []IndexData
-	err = lite.db.Select(&indexData, `
+	err = lite.session.Select(&indexData, `
 		SELECT 
 			ss.name AS TableName, 
 			pil.name AS IndexName, 
 			pil."unique" AS "Unique", 
 			pil."partial" AS "Partial", 
 			pii.seqno AS SeqNo, 
-			pii.name AS ColumnName 
+			pii.name AS ColumnName,
+			(SELECT si.sql FROM sqlite_schema si WHERE si.type = 'index' AND si.name = pil.name) AS Definition
 		FROM 
 			sqlite_schema ss 
 		INNER JOIN 
 			pragma_index_list(ss.name) pil 
 		INNER JOIN 
 			pragma_index_info(pil.name) pii
+		WHERE
+			ss.type = 'table'
+			AND ss.sql NOT LIKE 'CREATE VIRTUAL TABLE%'
 		ORDER BY 
 			pil.name DESC,
 			pii.seqno DESC;
@@ -102,155 +126,196 @@ func (lite *Sqlite) GetTables() ([]Table, error) {
 		return nil, err
 	}
 
-	indexes := map[string]Index{}
-	for _, v := range indexData {
-		indexVal, ok := indexes[v.IndexName]
-		if !ok {
-			newIndex := Index{
-				Name:      v.IndexName,
-				TableName: v.TableName,
-				Cols: []IndexCol{{
-					ColumnName: v.ColumnName,
-					Unique:     v.Unique,
-					Partial:    v.Partial,
-					SeqNo:      v.SeqNo,
-				}},
-			}
-
-			indexes[v.IndexName] = newIndex
-			continue
-		}
-
-		indexVal.Cols = append(indexVal.Cols, IndexCol{
-			ColumnName: v.ColumnName,
-			Unique:     v.Unique,
-			Partial:    v.Partial,
-			SeqNo:      v.SeqNo,
-		})
+	tables := groupTableData(tableData, indexData)
 
-		indexes[v.IndexName] = indexVal
+	// A foreign key without "to" columns references the primary key of the
+	// other table, they're looked up by position in the key.
+	var fkData []ForeignKeyData
+	err = lite.session.Select(&fkData, `
+		SELECT
+			ss.name AS TableName,
+			CAST(fk.id AS TEXT) AS ID,
+			fk.seq AS Seq,
+			fk."from" AS ColumnName,
+			fk."table" AS RefTable,
+			COALESCE(fk."to", (
+				SELECT pti.name FROM pragma_table_info(fk."table") pti WHERE pti.pk = fk.seq + 1
+			)) AS RefColumn,
+			fk.on_update AS OnUpdate,
+			fk.on_delete AS OnDelete
+		FROM
+			sqlite_schema ss
+		INNER JOIN
+			pragma_foreign_key_list(ss.name) fk
+		WHERE
+			ss.type = 'table'
+			AND ss.sql NOT LIKE 'CREATE VIRTUAL TABLE%'
+		ORDER BY
+			ss.name,
+			fk.id,
+			fk.seq;
+	`)
+	if err != nil {
+		return nil, err
 	}
+	attachForeignKeys(tables, fkData)
 
-	tableMap := make(map[string]Table)
-	for _, v := range tableData {
-		tableVal, ok := tableMap[v.TableName]
-
-		if !ok {
-			newTable := Table{
-				Name: v.TableName,
-				Columns: []Column{{
-					Name:         v.ColumnName,
-					Type:         v.Type,
-					NotNull:      v.NotNull,
-					DefaultValue: v.DefaultValue,
-					PrimaryKey:   v.PrimaryKey,
-					FKTo:         v.FKTo,
-					OnUpdate:     v.OnUpdate,
-					OnDelete:     v.OnDelete,
-				},
-				},
-			}
+	var virtual []ObjectDefinition
+	err = lite.session.Select(&virtual, `
+		SELECT
+			name AS Name,
+			sql AS Definition
+		FROM
+			sqlite_schema
+		WHERE
+			type = 'table'
+			AND sql LIKE 'CREATE VIRTUAL TABLE%'
+		ORDER BY
+			name;
+	`)
+	if err != nil {
+		return nil, err
+	}
 
-			tableMap[v.TableName] = newTable
-			continue
+	for _, v := range virtual {
+		table := Table{
+			Name: v.Name,
+			Type: VirtualTableObject,
 		}
 
-		col := Column{
-			Name:         v.ColumnName,
-			Type:         v.Type,
-			NotNull:      v.NotNull,
-			DefaultValue: v.DefaultValue,
-			PrimaryKey:   v.PrimaryKey,
-			FKTo:         v.FKTo,
-			OnUpdate:     v.OnUpdate,
-			OnDelete:     v.OnDelete,
+		var columns []TableData
+		err := lite.session.Select(&columns, `
+			SELECT
+				name AS ColumnName,
+				type AS Type,
+				"notnull" AS "NotNull",
+				dflt_value AS DefaultValue,
+				pk > 0 AS PrimaryKey
+			FROM
+				pragma_table_info(?);
+		`, v.Name)
+		if err == nil {
+			for _, col := range columns {
+				table.Columns = append(table.Columns, Column{
+					Name:         col.ColumnName,
+					Type:         col.Type,
+					NotNull:      col.NotNull,
+					DefaultValue: col.DefaultValue,
+					PrimaryKey:   col.PrimaryKey,
+				})
+			}
 		}
 
-		tableVal.Columns = append(tableVal.Columns, col)
-		tableMap[v.TableName] = tableVal
+		tables = append(tables, table)
 	}
 
-	for _, v := range indexes {
-		tableVal, ok := tableMap[v.TableName]
-		if ok {
-			tableVal.Indexes = append(tableVal.Indexes, v)
-			tableMap[v.TableName] = tableVal
-		}
+	var defs []ObjectDefinition
+	err = lite.session.Select(&defs, `
+		SELECT
+			name AS Name,
+			sql AS Definition
+		FROM
+			sqlite_schema
+		WHERE
+			type IN ('table', 'view');
+	`)
+	if err != nil {
+		return nil, err
 	}
+	setDefinitions(tables, defs)
 
-	var finalTables []Table
-	for _, v := range tableMap {
-		finalTables = append(finalTables, v)
+	triggers, err := lite.GetTriggers()
+	if err != nil {
+		return nil, err
 	}
+	attachTriggers(tables, triggers)
+
+	return tables, nil
+}
+
+func (lite *Sqlite) GetTriggers() ([]Trigger, error) {
+	var triggers []Trigger
+	err := lite.session.Select(&triggers, `
+		SELECT
+			name AS Name,
+			tbl_name AS TableName,
+			sql AS Definition
+		FROM
+			sqlite_schema
+		WHERE
+			type = 'trigger'
+		ORDER BY
+			name;
+	`)
 
-	return finalTables, nil
+	return triggers, err
 }
 
 func (lite *Sqlite) GetRoles() ([]RoleInfo, error) {
 	return nil, ErrNotSupported
 }
 
-func (lite *Sqlite) Select(cmd string) ([][][]rune, error) {
-	rows, err := lite.db.Query(cmd)
+func (lite *Sqlite) Select(ctx context.Context, cmd string, args ...any) (*ResultSet, error) {
+	return lite.session.Result(ctx, cmd, args...)
+}
+
+// Explain runs stmt under EXPLAIN QUERY PLAN.
+func (lite *Sqlite) Explain(ctx context.Context, stmt string) (*PlanNode, error) {
+	stmt, err := explainStatement("sqlite3", stmt)
 	if err != nil {
 		return nil, err
 	}
 
-	table, err := convertRowsToRuneArr(rows)
+	rows, err := lite.session.Query(ctx, "EXPLAIN QUERY PLAN "+stmt)
 	if err != nil {
 		return nil, err
 	}
 
-	return table, nil
+	return sqlitePlan(rows)
 }
 
-func (lite *Sqlite) Exec(cmd string) ([]rune, error) {
-	result, err := lite.db.Exec(cmd)
+func (lite *Sqlite) Exec(ctx context.Context, cmd string, args ...any) (int64, error) {
+	result, err := lite.session.Exec(ctx, cmd, args...)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return nil, err
-	}
+	return result.RowsAffected()
+}
 
-	return []rune(fmt.Sprintf("%d rows affected", rows)), nil
+func (lite *Sqlite) GetExecSQLFunc() ExecSQLFunc {
+	return createExecSQLFunc(lite, lite.tableDataFunc, lite.updateViewFunc, &lite.continueOnError, &lite.historyFunc)
 }
 
-func (lite *Sqlite) GetExecSQLFunc() components.ExecSQLFunc {
-	return func(cmd []rune) error {
-		if len(cmd) == 0 {
-			return nil
-		}
+func (lite *Sqlite) SetContinueOnError(continueOnError bool) {
+	lite.continueOnError = continueOnError
+}
 
-		cmdStr := string(cmd)
-		match, _ := regexp.MatchString(`(?i)(\s*|^)SELECT\s`, cmdStr)
+func (lite *Sqlite) SetHistoryFunc(historyFunc HistoryFunc) {
+	lite.historyFunc = historyFunc
+}
 
-		var err error
-		var table [][][]rune = nil
-		var result []rune = nil
-		if match {
-			table, err = lite.Select(cmdStr)
-			if err != nil {
-				return err
-			}
-		} else {
-			result, err = lite.Exec(cmdStr)
-			if err != nil {
-				return err
-			}
-		}
+func (lite *Sqlite) Begin() error {
+	return lite.session.Begin()
+}
 
-		matchTableUpdate, _ := regexp.MatchString(`(?i)(\s*|^)(CREATE\s*(TEMP\s*|TEMPORARY\s*)?(TABLE|INDEX)\s)|(DROP\s*TABLE\s)|(ALTER\s*TABLE\s)`, cmdStr)
-		if matchTableUpdate {
-			tables, err := lite.GetTables()
-			if err == nil {
-				lite.updateViewFunc(tables)
-			}
-		}
+func (lite *Sqlite) Commit() error {
+	return lite.session.Commit()
+}
 
-		lite.tableDataFunc(table, result)
-		return nil
-	}
+func (lite *Sqlite) Rollback() error {
+	return lite.session.Rollback()
+}
+
+func (lite *Sqlite) SetAutocommit(autocommit bool) {
+	lite.session.SetAutocommit(autocommit)
+}
+
+func (lite *Sqlite) Transaction() TxStatus {
+	return lite.session.Status()
+}
+
+func (lite *Sqlite) Close() error {
+	lite.session.Rollback()
+	return lite.db.Close()
 }
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type ValueKind byte
+
+const (
+	NullValue ValueKind = iota
+	NumberValue
+	BoolValue
+	TextValue
+	BytesValue
+	TimeValue
+)
+
+// ColumnInfo is the metadata for a result column taken from
+// sql.ColumnType, the Has fields are false when the driver doesn't report
+// that piece of information.
+type ColumnInfo struct {
+	Name         string
+	DatabaseType string
+	Nullable     bool
+	HasNullable  bool
+	Length       int64
+	HasLength    bool
+	Precision    int64
+	Scale        int64
+	HasPrecision bool
+}
+
+// Value is a single typed cell from a result. Raw holds the Go value for the
+// cell: nil, int64, float64, bool, string, []byte or time.Time. Numbers that
+// can't be held exactly, such as large decimals, are kept as a string.
+type Value struct {
+	Kind ValueKind
+	Raw  any
+}
+
+type Row []Value
+
+// Len, Text, IsNull and IsNumber let a Row be shown as a shared.Row.
+func (row Row) Len() int {
+	return len(row)
+}
+
+func (row Row) Text(col int) string {
+	return row[col].String()
+}
+
+func (row Row) IsNull(col int) bool {
+	return row[col].IsNull()
+}
+
+func (row Row) IsNumber(col int) bool {
+	return row[col].IsNumber()
+}
+
+func (v Value) IsNull() bool {
+	return v.Kind == NullValue
+}
+
+func (v Value) IsNumber() bool {
+	return v.Kind == NumberValue
+}
+
+func (v Value) String() string {
+	switch raw := v.Raw.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return raw
+	case int64:
+		return strconv.FormatInt(raw, 10)
+	case float64:
+		return strconv.FormatFloat(raw, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(raw)
+	case []byte:
+		return "0x" + hex.EncodeToString(raw)
+	case time.Time:
+		if raw.Hour() == 0 && raw.Minute() == 0 && raw.Second() == 0 && raw.Nanosecond() == 0 {
+			return raw.Format(time.DateOnly)
+		}
+		return raw.Format("2006-01-02 15:04:05.999999999Z07:00")
+	}
+
+	return fmt.Sprint(v.Raw)
+}
+
+func TextVal(text string) Value {
+	return Value{Kind: TextValue, Raw: text}
+}
+
+func IntVal(n int64) Value {
+	return Value{Kind: NumberValue, Raw: n}
+}
+
+// TextColumns builds untyped column info for results sqline makes itself,
+// like script summaries.
+func TextColumns(names ...string) []ColumnInfo {
+	var cols []ColumnInfo
+	for _, v := range names {
+		cols = append(cols, ColumnInfo{Name: v})
+	}
+
+	return cols
+}
+
+func columnInfo(colTypes []*sql.ColumnType) []ColumnInfo {
+	var cols []ColumnInfo
+	for _, v := range colTypes {
+		col := ColumnInfo{
+			Name:         v.Name(),
+			DatabaseType: v.DatabaseTypeName(),
+		}
+
+		col.Nullable, col.HasNullable = v.Nullable()
+		col.Length, col.HasLength = v.Length()
+		col.Precision, col.Scale, col.HasPrecision = v.DecimalSize()
+		cols = append(cols, col)
+	}
+
+	return cols
+}
+
+// toValue converts a value scanned into an any to a typed Value, drivers
+// that send everything as text (like MySQL) are typed using the column's
+// database type.
+func toValue(raw any, col ColumnInfo) Value {
+	dbType := strings.ToUpper(col.DatabaseType)
+
+	switch raw := raw.(type) {
+	case nil:
+		return Value{Kind: NullValue}
+	case int64:
+		if dbType == "BOOL" || dbType == "BOOLEAN" || dbType == "BIT" {
+			return Value{Kind: BoolValue, Raw: raw != 0}
+		}
+		return Value{Kind: NumberValue, Raw: raw}
+	case float64:
+		return Value{Kind: NumberValue, Raw: raw}
+	case float32:
+		return Value{Kind: NumberValue, Raw: float64(raw)}
+	case bool:
+		return Value{Kind: BoolValue, Raw: raw}
+	case time.Time:
+		return Value{Kind: TimeValue, Raw: raw}
+	case string:
+		return textValue(raw, dbType)
+	case []byte:
+		if isBinaryType(dbType) || !utf8.Valid(raw) {
+			return Value{Kind: BytesValue, Raw: append([]byte{}, raw...)}
+		}
+		return textValue(string(raw), dbType)
+	}
+
+	return TextVal(fmt.Sprint(raw))
+}
+
+func textValue(text, dbType string) Value {
+	if !isNumericType(dbType) {
+		return TextVal(text)
+	}
+
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return IntVal(n)
+	}
+
+	return Value{Kind: NumberValue, Raw: text}
+}
+
+func isNumericType(dbType string) bool {
+	switch dbType {
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "INT2", "INT4", "INT8",
+		"UNSIGNED INT", "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED BIGINT",
+		"DECIMAL", "NUMERIC", "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "MONEY", "SMALLMONEY":
+		return true
+	}
+
+	return false
+}
+
+func isBinaryType(dbType string) bool {
+	switch dbType {
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA", "BINARY", "VARBINARY", "IMAGE":
+		return true
+	}
+
+	return false
+}
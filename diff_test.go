@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func createSchema(t *testing.T, script string) *db.Sqlite {
+	t.Helper()
+
+	lite, err := db.CreateSqlite(":memory:", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lite.Close() })
+
+	_, err = lite.Exec(context.Background(), script)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return lite
+}
+
+func getTables(t *testing.T, lite *db.Sqlite) []db.Table {
+	t.Helper()
+
+	tables, err := lite.GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tables
+}
+
+func TestSchemaDiff(t *testing.T) {
+	from := createSchema(t, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, legacy TEXT);
+		CREATE INDEX users_legacy ON users (legacy);
+		CREATE TABLE old_logs (id INTEGER PRIMARY KEY);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER, title TEXT);
+		INSERT INTO users (id, name, legacy) VALUES (1, 'ada', 'x');
+		INSERT INTO posts (id, user_id, title) VALUES (1, 1, 'hello');
+	`)
+
+	to := createSchema(t, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL DEFAULT '', email TEXT);
+		CREATE UNIQUE INDEX users_email ON users (email);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id) ON DELETE CASCADE, title TEXT);
+		CREATE TABLE tags (post_id INTEGER, tag TEXT, PRIMARY KEY (post_id, tag));
+		CREATE VIEW user_names AS SELECT name FROM users;
+	`)
+
+	diff := db.DiffSchemas(getTables(t, from), getTables(t, to))
+
+	kinds := make(map[string]db.ChangeKind)
+	for _, v := range diff.Tables {
+		kinds[v.Name] = v.Kind
+	}
+	if len(kinds) != 4 || kinds["users"] != db.Changed || kinds["posts"] != db.Changed || kinds["old_logs"] != db.Removed || kinds["tags"] != db.Added {
+		t.Fatalf("unexpected tables in diff %+v", kinds)
+	}
+
+	var summaries []string
+	for _, v := range diff.Tables {
+		if v.Name != "users" {
+			continue
+		}
+		for _, col := range v.Columns {
+			summaries = append(summaries, col.Summary())
+		}
+		for _, index := range v.Indexes {
+			summaries = append(summaries, index.Summary())
+		}
+	}
+
+	expected := []string{
+		"~ column name: NOT NULL added, default none → ''",
+		"+ column email TEXT",
+		"- column legacy TEXT",
+		"+ index users_email UNIQUE (email)",
+		"- index users_legacy (legacy)",
+	}
+	if strings.Join(summaries, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected users diff\n%s", strings.Join(summaries, "\n"))
+	}
+
+	sql := diff.MigrationSQL("sqlite3")
+	_, err := from.Exec(context.Background(), sql)
+	if err != nil {
+		t.Fatalf("migration failed: %v\n%s", err, sql)
+	}
+
+	after := db.DiffSchemas(getTables(t, from), getTables(t, to))
+	if !after.Empty() {
+		t.Fatalf("schemas still differ after migrating: %+v\n%s", after.Tables, sql)
+	}
+
+	rows, err := from.Select(context.Background(), "SELECT name, email IS NULL FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := readRows(t, rows)
+	if len(data) != 2 || string(data[1][0]) != "ada" || string(data[1][1]) != "1" {
+		t.Fatalf("rows weren't copied over when rebuilding users: %v", data)
+	}
+}
+
+func TestMigrationRebuildName(t *testing.T) {
+	from := createSchema(t, `
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT);
+		CREATE TABLE posts_new (id INTEGER PRIMARY KEY);
+		CREATE VIEW posts_new2 AS SELECT id FROM posts_new;
+		INSERT INTO posts VALUES (1, 'hello');
+		INSERT INTO posts_new VALUES (7);
+	`)
+
+	to := createSchema(t, `
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT NOT NULL DEFAULT '');
+		CREATE TABLE posts_new (id INTEGER PRIMARY KEY);
+	`)
+
+	sql := db.DiffSchemas(getTables(t, from), getTables(t, to)).MigrationSQL("sqlite3")
+	if !strings.Contains(sql, `CREATE TABLE "posts_new3"`) {
+		t.Fatalf("expected the rebuild to use a free name\n%s", sql)
+	}
+
+	if _, err := from.Exec(context.Background(), sql); err != nil {
+		t.Fatalf("migration failed: %v\n%s", err, sql)
+	}
+
+	data := readRows(t, selectResult(t, from, "SELECT (SELECT title FROM posts), (SELECT id FROM posts_new)"))
+	if string(data[1][0]) != "hello" || string(data[1][1]) != "7" {
+		t.Fatalf("unexpected rows after rebuilding posts: %q", data)
+	}
+}
+
+func TestMigrationSQLDialects(t *testing.T) {
+	defaultValue := "0"
+	from := []db.Table{{
+		Name: "accounts",
+		Columns: []db.Column{
+			{Name: "id", Type: "integer", NotNull: true, PrimaryKey: true},
+			{Name: "balance", Type: "integer"},
+		},
+	}}
+	to := []db.Table{{
+		Name: "accounts",
+		Columns: []db.Column{
+			{Name: "id", Type: "integer", NotNull: true, PrimaryKey: true},
+			{Name: "balance", Type: "bigint", NotNull: true, DefaultValue: &defaultValue},
+		},
+		ForeignKeys: []db.ForeignKey{{
+			ID:         "accounts_id_fkey",
+			Columns:    []string{"id"},
+			RefTable:   "app.owners",
+			RefColumns: []string{"id"},
+			OnDelete:   "CASCADE",
+		}},
+	}}
+
+	diff := db.DiffSchemas(from, to)
+
+	expected := map[string]string{
+		"postgres": `ALTER TABLE "accounts" ALTER COLUMN "balance" TYPE bigint;
+ALTER TABLE "accounts" ALTER COLUMN "balance" SET NOT NULL;
+ALTER TABLE "accounts" ALTER COLUMN "balance" SET DEFAULT 0;
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_id_fkey" FOREIGN KEY ("id") REFERENCES "app"."owners" ("id") ON DELETE CASCADE;
+`,
+		"mysql": "ALTER TABLE `accounts` MODIFY COLUMN `balance` bigint NOT NULL DEFAULT 0;\n" +
+			"ALTER TABLE `accounts` ADD CONSTRAINT `accounts_id_fkey` FOREIGN KEY (`id`) REFERENCES `app.owners` (`id`) ON DELETE CASCADE;\n",
+		"sqlserver": `ALTER TABLE [accounts] ALTER COLUMN [balance] bigint NOT NULL;
+ALTER TABLE [accounts] ADD DEFAULT 0 FOR [balance];
+ALTER TABLE [accounts] ADD CONSTRAINT [accounts_id_fkey] FOREIGN KEY ([id]) REFERENCES [app].[owners] ([id]) ON DELETE CASCADE;
+`,
+	}
+
+	for driver, sql := range expected {
+		if got := diff.MigrationSQL(driver); got != sql {
+			t.Errorf("unexpected %s migration\n%s", driver, got)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestDumpSqlite(t *testing.T) {
+	source := createSchema(t, `
+		CREATE TABLE authors (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, avatar BLOB);
+		CREATE TABLE books (id INTEGER PRIMARY KEY, author_id INTEGER REFERENCES zines (id), title TEXT, pages INTEGER);
+		CREATE TABLE zines (id INTEGER PRIMARY KEY, editor_id INTEGER REFERENCES authors (id));
+		CREATE TABLE log (msg TEXT);
+		CREATE UNIQUE INDEX books_title ON books (title);
+		CREATE VIEW titles AS SELECT title FROM books;
+		CREATE TRIGGER books_log AFTER INSERT ON books BEGIN INSERT INTO log (msg) VALUES ('added ' || NEW.title); END;
+		INSERT INTO authors (name, avatar) VALUES ('O''Brien', X'00FF'), ('line
+break', NULL);
+		INSERT INTO zines (id, editor_id) VALUES (1, 2);
+		INSERT INTO books (id, author_id, title, pages) VALUES (1, 1, 'it''s', 'many'), (2, NULL, NULL, 12);
+		DELETE FROM authors WHERE id = 2;
+	`)
+
+	var buf bytes.Buffer
+	var progress []string
+	err := db.Dump(context.Background(), &buf, source, func(table string, done, total int) {
+		progress = append(progress, table)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := buf.String()
+	if strings.Join(progress, ",") != "authors,zines,books,log,sqlite_sequence," {
+		t.Fatalf("tables weren't dumped after the tables they reference: %v\n%s", progress, script)
+	}
+
+	target := createSchema(t, "PRAGMA foreign_keys = ON;")
+	_, err = target.Exec(context.Background(), script)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, script)
+	}
+
+	diff := db.DiffSchemas(getTables(t, source), getTables(t, target))
+	if !diff.Empty() {
+		t.Fatalf("schemas differ after loading the dump %+v\n%s", diff.Tables, script)
+	}
+
+	for _, query := range []string{
+		"SELECT id, name, hex(avatar), typeof(avatar) FROM authors ORDER BY id",
+		"SELECT id, author_id, title, pages, typeof(pages) FROM books ORDER BY id",
+		"SELECT msg FROM log ORDER BY rowid",
+		"SELECT name, seq FROM sqlite_sequence",
+		"SELECT * FROM titles",
+	} {
+		want, err := source.Select(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := target.Select(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantRows, gotRows := readRows(t, want), readRows(t, got)
+		for i := range wantRows {
+			for j := range wantRows[i] {
+				if i >= len(gotRows) || string(wantRows[i][j]) != string(gotRows[i][j]) {
+					t.Fatalf("%s returned different rows after loading the dump\nwant %q\ngot  %q\n%s", query, wantRows, gotRows, script)
+				}
+			}
+		}
+		if len(wantRows) != len(gotRows) {
+			t.Fatalf("%s returned %d rows after loading the dump, expected %d", query, len(gotRows), len(wantRows))
+		}
+	}
+}
+
+func TestDumpSequenceLast(t *testing.T) {
+	source := createSchema(t, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);
+		INSERT INTO users (name) VALUES ('a'), ('b'), ('c');
+		DELETE FROM users WHERE id = 3;
+	`)
+
+	var buf bytes.Buffer
+	err := db.Dump(context.Background(), &buf, source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := buf.String()
+	target := createSchema(t, "")
+	_, err = target.Exec(context.Background(), script)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, script)
+	}
+
+	rs, err := target.Select(context.Background(), "SELECT name, seq FROM sqlite_sequence")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readRows(t, rs)
+	if len(rows) != 2 || string(rows[1][0]) != "users" || string(rows[1][1]) != "3" {
+		t.Fatalf("sqlite_sequence wasn't restored %q\n%s", rows, script)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func selectResult(t *testing.T, lite *db.Sqlite, stmt string) *db.ResultSet {
+	t.Helper()
+
+	results := db.RunScript(context.Background(), lite, stmt, false, nil)
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("running %q: %+v", stmt, results)
+	}
+
+	return results[0].Rows
+}
+
+func TestEditTarget(t *testing.T) {
+	lite := createSchema(t, `
+		CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT, photo BLOB);
+		CREATE TABLE pairs (a INTEGER, b INTEGER, note TEXT, PRIMARY KEY (a, b));
+		CREATE TABLE loose (x TEXT);
+		CREATE VIEW names AS SELECT name FROM people;
+		INSERT INTO people VALUES (1, 'Ann', NULL);
+		INSERT INTO pairs VALUES (1, 2, NULL);
+		INSERT INTO loose VALUES ('x');
+	`)
+	tables := getTables(t, lite)
+
+	tests := []struct {
+		stmt string
+		cols string
+		err  string
+	}{
+		{"SELECT * FROM people WHERE id = 1", "[id name photo]", ""},
+		{"select p.name, upper(name), \"ID\" from main.people AS p order by 1 limit 5", "[name  id]", ""},
+		{"SELECT note, b, a FROM pairs -- comment", "[note b a]", ""},
+		{"SELECT name FROM people", "", "the primary key column id isn't in the results so rows can't be matched to edit them"},
+		{"SELECT * FROM loose", "", "loose has no primary key so its rows can't be edited"},
+		{"SELECT * FROM names", "", "names isn't a table so its rows can't be edited"},
+		{"SELECT * FROM people JOIN pairs ON a = id", "", db.ErrNotEditable.Error()},
+		{"SELECT * FROM people, pairs", "", db.ErrNotEditable.Error()},
+		{"SELECT count(*), id FROM people GROUP BY id", "", db.ErrNotEditable.Error()},
+		{"SELECT id FROM people UNION SELECT a FROM pairs", "", db.ErrNotEditable.Error()},
+		{"WITH x AS (SELECT 1) SELECT * FROM people", "", db.ErrNotEditable.Error()},
+		{"SELECT * FROM people WHERE id IN (SELECT a FROM pairs)", "[id name photo]", ""},
+	}
+
+	for _, tt := range tests {
+		rs := selectResult(t, lite, tt.stmt)
+		target, err := db.FindEditTarget("sqlite3", rs.Statement(), tables, rs.Columns())
+		rs.Close()
+
+		switch {
+		case tt.err != "" && (err == nil || err.Error() != tt.err):
+			t.Errorf("%q: expected error %q, got %v", tt.stmt, tt.err, err)
+		case tt.err == "" && err != nil:
+			t.Errorf("%q: unexpected error %v", tt.stmt, err)
+		case tt.err == "" && fmt.Sprint(target.Columns) != tt.cols:
+			t.Errorf("%q: expected columns %s, got %q", tt.stmt, tt.cols, target.Columns)
+		}
+	}
+
+	rs := selectResult(t, lite, "SELECT name, upper(name), id, photo FROM people")
+	defer rs.Close()
+	target, err := db.FindEditTarget("sqlite3", rs.Statement(), tables, rs.Columns())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = target.Editable(1, rs.Columns()); err == nil || !strings.Contains(err.Error(), "isn't a column of people") {
+		t.Fatalf("expected the expression column to be refused, got %v", err)
+	}
+	if err = target.Editable(0, rs.Columns()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEditUpdate(t *testing.T) {
+	lite := createSchema(t, `
+		CREATE TABLE pairs (a INTEGER, b TEXT, note TEXT, PRIMARY KEY (a, b));
+		INSERT INTO pairs VALUES (1, 'x', 'old'), (1, 'y', 'keep');
+	`)
+	ctx := context.Background()
+
+	rs := selectResult(t, lite, "SELECT * FROM pairs ORDER BY b")
+	rows, err := rs.Next(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := db.FindEditTarget("sqlite3", rs.Statement(), getTables(t, lite), rs.Columns())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := "it's new"
+	stmt, err := target.Update("sqlite3", rows[0], 2, &value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `UPDATE "pairs" SET "note" = ? WHERE "a" = ? AND "b" = ?;` + "\n-- values: 'it''s new', 1, 'x'"
+	if stmt.String() != expected {
+		t.Fatalf("unexpected statement %s", stmt)
+	}
+
+	affected, err := stmt.Exec(ctx, lite)
+	if err != nil || affected != 1 {
+		t.Fatalf("expected one row updated, got %d (%v)", affected, err)
+	}
+
+	stmt, err = target.Update("sqlite3", rows[1], 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stmt.Exec(ctx, lite); err != nil {
+		t.Fatal(err)
+	}
+
+	check := selectResult(t, lite, "SELECT note FROM pairs ORDER BY b")
+	got := readRows(t, check)
+	if len(got) != 3 || string(got[1][0]) != value || string(got[2][0]) != "NULL" {
+		t.Fatalf("unexpected rows after updating %q", got)
+	}
+
+	pg, err := target.Update("postgres", rows[0], 0, &value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pg.SQL != `UPDATE "pairs" SET "a" = $1 WHERE "a" = $2 AND "b" = $3` {
+		t.Fatalf("unexpected postgres statement %s", pg.SQL)
+	}
+}
+
+func TestEditTargetSchemas(t *testing.T) {
+	users := func(name string) db.Table {
+		return db.Table{Name: name, Type: db.TableObject, Columns: []db.Column{
+			{Name: "id", Type: "integer", PrimaryKey: true},
+			{Name: "name", Type: "text"},
+		}}
+	}
+	columns := []db.ColumnInfo{{Name: "id"}, {Name: "name"}}
+
+	tests := []struct {
+		driver string
+		tables []db.Table
+		stmt   string
+		table  string
+	}{
+		{"postgres", []db.Table{users("users"), users("other.users")}, "SELECT * FROM other.users", "other.users"},
+		{"postgres", []db.Table{users("users"), users("other.users")}, "SELECT * FROM Other.Users", "other.users"},
+		{"postgres", []db.Table{users("users"), users("other.users")}, "SELECT * FROM public.users", "users"},
+		{"postgres", []db.Table{users("users"), users("other.users")}, "SELECT * FROM users", "users"},
+		{"postgres", []db.Table{users("users"), users("other.users")}, "SELECT * FROM zzz.users", ""},
+		{"sqlserver", []db.Table{users("users"), users("other.users")}, "SELECT * FROM [dbo].[users]", "users"},
+		{"sqlserver", []db.Table{users("users"), users("other.users")}, "SELECT * FROM sales.users", ""},
+		{"mysql", []db.Table{users("users")}, "SELECT * FROM shop.users", "users"},
+	}
+
+	for _, tt := range tests {
+		target, err := db.FindEditTarget(tt.driver, tt.stmt, tt.tables, columns)
+		switch {
+		case tt.table == "" && err == nil:
+			t.Errorf("%s %q: expected no table, got %s", tt.driver, tt.stmt, target.Table.Name)
+		case tt.table != "" && err != nil:
+			t.Errorf("%s %q: unexpected error %v", tt.driver, tt.stmt, err)
+		case tt.table != "" && target.Table.Name != tt.table:
+			t.Errorf("%s %q: expected %s, got %s", tt.driver, tt.stmt, tt.table, target.Table.Name)
+		}
+	}
+}
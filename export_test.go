@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func exportRows() ([]db.ColumnInfo, []db.Row) {
+	columns := db.TextColumns("id", "note", "score")
+	rows := []db.Row{
+		{db.IntVal(1), db.TextVal("line one\nline \"two\"\tand a | pipe"), {Kind: db.NumberValue, Raw: 1.5}},
+		{db.IntVal(2), {Kind: db.NullValue}, {Kind: db.NullValue}},
+		{db.IntVal(3), db.TextVal("it's"), {Kind: db.BytesValue, Raw: []byte{0xde, 0xad}}},
+	}
+
+	return columns, rows
+}
+
+func export(t *testing.T, format db.ExportFormat, table, driver string) string {
+	t.Helper()
+
+	columns, rows := exportRows()
+	var buf bytes.Buffer
+	err := db.Export(&buf, format, columns, rows, table, driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestExportCSV(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewBufferString(export(t, db.CSVExport, "", ""))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 4 || records[1][1] != "line one\nline \"two\"\tand a | pipe" || records[2][1] != "" || records[3][2] != "0xdead" {
+		t.Fatalf("unexpected CSV records %q", records)
+	}
+}
+
+func TestExportTSV(t *testing.T) {
+	expected := "id\tnote\tscore\n" +
+		"1\tline one\\nline \"two\"\\tand a | pipe\t1.5\n" +
+		"2\t\\N\t\\N\n" +
+		"3\tit's\t0xdead\n"
+
+	if got := export(t, db.TSVExport, "", ""); got != expected {
+		t.Fatalf("unexpected TSV\n%s", got)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	var objects []map[string]any
+	err := json.Unmarshal([]byte(export(t, db.JSONExport, "", "")), &objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 3 || objects[0]["id"] != 1.0 || objects[0]["note"] != "line one\nline \"two\"\tand a | pipe" || objects[0]["score"] != 1.5 {
+		t.Fatalf("unexpected first object %v", objects[0])
+	}
+	if v, ok := objects[1]["note"]; !ok || v != nil {
+		t.Fatalf("expected note to be null, got %v", objects[1])
+	}
+
+	columns, _ := exportRows()
+	var buf bytes.Buffer
+	err = db.Export(&buf, db.JSONExport, columns, nil, "", "")
+	if err != nil || buf.String() != "[]\n" {
+		t.Fatalf("expected an empty array for no rows, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	expected := "| id | note | score |\n" +
+		"| --- | --- | --- |\n" +
+		"| 1 | line one<br>line \"two\"\tand a \\| pipe | 1.5 |\n" +
+		"| 2 | NULL | NULL |\n" +
+		"| 3 | it's | 0xdead |\n"
+
+	if got := export(t, db.MarkdownExport, "", ""); got != expected {
+		t.Fatalf("unexpected Markdown\n%s", got)
+	}
+}
+
+func TestExportInserts(t *testing.T) {
+	columns, rows := exportRows()
+	err := db.Export(&bytes.Buffer{}, db.InsertExport, columns, rows, "", "sqlite3")
+	if err != db.ErrExportTable {
+		t.Fatalf("expected ErrExportTable without a table, got %v", err)
+	}
+
+	lite, err := db.CreateSqlite(":memory:", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lite.Close()
+
+	script := export(t, db.InsertExport, "notes", "sqlite3")
+	_, err = lite.Exec(context.Background(), "CREATE TABLE notes (id INTEGER, note TEXT, score); "+script)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, script)
+	}
+
+	result, err := lite.Select(context.Background(), "SELECT note, score IS NULL, hex(score) FROM notes ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := readRows(t, result)
+	if string(data[1][0]) != "line one\nline \"two\"\tand a | pipe" || string(data[2][1]) != "1" || string(data[3][0]) != "it's" || string(data[3][2]) != "DEAD" {
+		t.Fatalf("unexpected rows after running the INSERTs %q\n%s", data, script)
+	}
+}
+
+func TestExportInsertsBackslash(t *testing.T) {
+	columns := db.TextColumns("path")
+	rows := []db.Row{{db.TextVal(`C:\temp\'x`)}}
+
+	for driver, want := range map[string]string{
+		"mysql":   `'C:\\temp\\''x'`,
+		"sqlite3": `'C:\temp\''x'`,
+	} {
+		var buf bytes.Buffer
+		err := db.Export(&buf, db.InsertExport, columns, rows, "files", driver)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %s in the %s INSERT, got %s", want, driver, buf.String())
+		}
+	}
+}
+
+func TestExportInsertsTime(t *testing.T) {
+	columns := db.TextColumns("at")
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("", 2*60*60))
+
+	for driver, want := range map[string]string{
+		"postgres":  `'2024-05-06 07:08:09+02:00'`,
+		"mysql":     `'2024-05-06 07:08:09+02:00'`,
+		"sqlserver": `'2024-05-06 05:08:09'`,
+	} {
+		value := at
+		if driver == "sqlserver" {
+			value = at.UTC()
+		}
+
+		var buf bytes.Buffer
+		err := db.Export(&buf, db.InsertExport, columns, []db.Row{{{Kind: db.TimeValue, Raw: value}}}, "events", driver)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %s in the %s INSERT, got %s", want, driver, buf.String())
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sleepy-day/sqline/db"
+	"github.com/sleepy-day/sqline/util"
+)
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sqline", "history.jsonl")
+
+	entries, err := util.LoadHistory(path)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty history without a file, got %v (%v)", entries, err)
+	}
+
+	ranAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = util.AppendHistory(path,
+		util.HistoryEntry{Connection: "local", Statement: "SELECT * FROM users", RanAt: ranAt, Duration: time.Millisecond, Rows: 3},
+		util.HistoryEntry{Connection: "local", Statement: "DELETE FROM sessions", RanAt: ranAt, Rows: -1, Error: "no such table: sessions"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+
+	err = util.AppendHistory(path, util.HistoryEntry{Connection: "prod", Statement: "SELECT name FROM users WHERE id = 1", RanAt: ranAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := util.HistoryEntry{Connection: "local", Statement: "SELECT * FROM users", RanAt: ranAt, Duration: time.Millisecond, Rows: 3}
+	err = util.AppendHistory(path, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated.Rows = 700
+	err = util.UpdateHistory(path, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err = util.LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[3].Rows != 700 {
+		t.Fatalf("expected the newest matching entry to be updated, got %+v", entries)
+	}
+	entries = entries[:3]
+
+	if len(entries) != 3 || entries[0].Rows != 3 || !entries[0].RanAt.Equal(ranAt) || entries[1].Error == "" || entries[2].Connection != "prod" {
+		t.Fatalf("unexpected entries read back %+v", entries)
+	}
+
+	var statements []string
+	for _, v := range util.SearchHistory(entries, "") {
+		statements = append(statements, v.Statement)
+	}
+	if len(statements) != 3 || statements[0] != "SELECT name FROM users WHERE id = 1" {
+		t.Fatalf("expected the newest entry first without a query, got %q", statements)
+	}
+
+	results := util.SearchHistory(entries, "sel usr")
+	if len(results) != 2 || results[0].Connection != "prod" {
+		t.Fatalf("expected equal matches newest first, got %+v", results)
+	}
+
+	results = util.SearchHistory(entries, "dlt sess")
+	if len(results) != 1 || results[0].Statement != "DELETE FROM sessions" {
+		t.Fatalf("unexpected search results %+v", results)
+	}
+
+	together, _ := util.FuzzyScore("users", "SELECT * FROM users")
+	apart, _ := util.FuzzyScore("users", "UPDATE sessions SET expires = r")
+	if together <= apart {
+		t.Fatalf("expected a contiguous match to score higher, got %d and %d", together, apart)
+	}
+
+	if results := util.SearchHistory(entries, "sessions drop"); len(results) != 0 {
+		t.Fatalf("expected no matches, got %+v", results)
+	}
+}
+
+func TestHistoryFunc(t *testing.T) {
+	lite, err := db.CreateSqlite(":memory:", func(*db.ResultSet, []rune) {}, func([]db.Table) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lite.Close()
+
+	var recorded []db.StatementResult
+	lite.SetContinueOnError(true)
+	lite.SetHistoryFunc(func(results []db.StatementResult) {
+		recorded = append(recorded, results...)
+	})
+
+	exec := lite.GetExecSQLFunc()
+	exec(context.Background(), []rune("CREATE TABLE t (a); INSERT INTO t VALUES (1), (2); SELECT * FROM t; SELECT nope FROM t;"), nil)
+	exec(context.Background(), []rune("SELECT * FROM t"), nil)
+
+	if len(recorded) != 5 {
+		t.Fatalf("expected 5 statements to be recorded, got %d", len(recorded))
+	}
+
+	for i, expected := range map[int]int64{1: 2, 2: 2, 3: -1, 4: 2} {
+		if got := recorded[i].Affected(); got != expected {
+			t.Errorf("expected %d rows for %q, got %d", expected, recorded[i].Statement, got)
+		}
+	}
+
+	if recorded[3].Err == nil || recorded[0].Start.IsZero() || recorded[0].Duration <= 0 {
+		t.Fatalf("unexpected results %+v", recorded)
+	}
+}
+
+func TestHistoryStreamedSelect(t *testing.T) {
+	var rows *db.ResultSet
+	lite, err := db.CreateSqlite(":memory:", func(rs *db.ResultSet, _ []rune) { rows = rs }, func([]db.Table) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lite.Close()
+
+	var recorded []db.StatementResult
+	lite.SetHistoryFunc(func(results []db.StatementResult) {
+		recorded = append(recorded, results...)
+	})
+
+	exec := lite.GetExecSQLFunc()
+	query := "WITH RECURSIVE n (i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 500) SELECT i FROM n"
+	for _, stop := range []bool{false, true} {
+		recorded = nil
+		if err = exec(context.Background(), []rune(query), nil); err != nil {
+			t.Fatal(err)
+		}
+		streamed := rows
+
+		if err = exec(context.Background(), []rune("SELECT 1"), nil); err != nil {
+			t.Fatal(err)
+		}
+		if len(recorded) != 2 || recorded[0].Statement != query || recorded[0].Affected() != -1 || recorded[1].Affected() != 1 {
+			t.Fatalf("expected the streamed SELECT to be recorded first with an unknown count, got %+v", recorded)
+		}
+
+		counted := make(chan int64, 1)
+		streamed.WhenFinished(func(count int64) {
+			counted <- count
+		})
+
+		expected := int64(500)
+		if stop {
+			streamed.Close()
+			expected = -1
+		} else if _, err = streamed.Next(0); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case count := <-counted:
+			if count != expected {
+				t.Fatalf("expected %d rows once fetching finished, got %d", expected, count)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the row count wasn't given once fetching finished")
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func readImport(t *testing.T, text string, format db.ExportFormat) *db.ImportData {
+	t.Helper()
+
+	data, err := db.ReadDelimited(strings.NewReader(text), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func findTable(t *testing.T, lite *db.Sqlite, name string) db.Table {
+	t.Helper()
+
+	for _, v := range getTables(t, lite) {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	t.Fatalf("no table named %s", name)
+	return db.Table{}
+}
+
+func TestImportNewTable(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("\ufeffid,price,code,day,seen at,note,id\n")
+	for i := range 450 {
+		fmt.Fprintf(&sb, "%d,%d.5,%03d,2024-01-%02d,2024-01-02 10:00:%02d,\"it's, \"\"quoted\"\"\",x\n", i, i, i, i%28+1, i%60)
+	}
+	sb.WriteString("450,7,12,,2024-01-02,,x\n")
+
+	data := readImport(t, sb.String(), db.CSVExport)
+
+	expected := "id INTEGER, price REAL, code TEXT, day TEXT, seen at TEXT, note TEXT, id_2 TEXT"
+	var columns []string
+	for _, v := range db.InferColumns(data, "sqlite3") {
+		columns = append(columns, v.Name+" "+v.Type)
+	}
+	if got := strings.Join(columns, ", "); got != expected {
+		t.Fatalf("unexpected inferred columns %s", got)
+	}
+
+	var types []string
+	for _, v := range db.InferColumns(data, "postgres") {
+		types = append(types, v.Type)
+	}
+	if got := strings.Join(types, ", "); got != "bigint, double precision, text, date, timestamp, text, text" {
+		t.Fatalf("unexpected postgres types %s", got)
+	}
+
+	lite := createSchema(t, "SELECT 1")
+	plan, err := db.NewTablePlan(data, "scratch", "sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []int
+	err = db.Import(context.Background(), lite, data, plan, func(done, total int) {
+		if total != 451 {
+			t.Errorf("expected 451 rows in total, got %d", total)
+		}
+		progress = append(progress, done)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(progress) != "[200 400 451]" {
+		t.Fatalf("unexpected progress %v", progress)
+	}
+	if lite.Transaction().Open {
+		t.Fatal("the import's transaction was left open")
+	}
+
+	result, err := lite.Select(context.Background(), `SELECT count(*), sum(id), typeof(max(price)), max(code), count(day), max(note), count(note) FROM scratch`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := readRows(t, result)[1]
+	if string(row[0]) != "451" || string(row[1]) != "101475" || string(row[2]) != "real" || string(row[3]) != "449" || string(row[4]) != "450" || string(row[5]) != `it's, "quoted"` || string(row[6]) != "451" {
+		t.Fatalf("unexpected rows after importing %q", row)
+	}
+}
+
+func TestImportAppend(t *testing.T) {
+	lite := createSchema(t, `CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT, score REAL NOT NULL DEFAULT 0)`)
+
+	columns, rows := exportRows()
+	var buf bytes.Buffer
+	err := db.Export(&buf, db.TSVExport, columns, rows, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := readImport(t, buf.String(), db.TSVExport)
+	if data.Rows[1][1] != nil || *data.Rows[0][1] != "line one\nline \"two\"\tand a | pipe" {
+		t.Fatalf("TSV wasn't read back the way it was exported %v", data.Rows)
+	}
+
+	table := findTable(t, lite, "notes")
+	if got := fmt.Sprint(db.AutoMapping(data, table)); got != "[0 -1 2]" {
+		t.Fatalf("unexpected automatic mapping %s", got)
+	}
+
+	for _, fields := range [][]int{{-1, 5, -1}, {-1, -1, -1}, nil} {
+		_, err = db.AppendPlan(data, table, fields)
+		if err == nil {
+			t.Errorf("expected an error for %v", fields)
+		}
+	}
+
+	plan, err := db.AppendPlan(data, table, []int{0, 1, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Import(context.Background(), lite, data, plan, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := lite.Select(context.Background(), "SELECT id, body, score FROM notes ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data2 := readRows(t, result)
+	if len(data2) != 4 || string(data2[1][1]) != "line one\nline \"two\"\tand a | pipe" || string(data2[2][1]) != "NULL" || string(data2[3][2]) != "0" {
+		t.Fatalf("unexpected rows after appending %q", data2)
+	}
+
+	// The ids are already taken so the second import fails and rolls back.
+	err = db.Import(context.Background(), lite, data, plan, nil)
+	if err == nil {
+		t.Fatal("expected the duplicate ids to fail")
+	}
+	if lite.Transaction().Open {
+		t.Fatal("the failed import's transaction was left open")
+	}
+
+	err = lite.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lite.Rollback()
+
+	err = db.Import(context.Background(), lite, data, plan, nil)
+	if err != db.ErrImportTransaction {
+		t.Fatalf("expected ErrImportTransaction with a transaction open, got %v", err)
+	}
+}
+
+func TestImportEmptyFields(t *testing.T) {
+	lite := createSchema(t, `CREATE TABLE people (id INTEGER PRIMARY KEY, name VARCHAR(20) NOT NULL, age INTEGER)`)
+
+	data := readImport(t, "id,name,age\n1,,\n2,Ann,30\n", db.CSVExport)
+	table := findTable(t, lite, "people")
+	plan, err := db.AppendPlan(data, table, db.AutoMapping(data, table))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Import(context.Background(), lite, data, plan, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := lite.Select(context.Background(), "SELECT quote(name), quote(age) FROM people ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readRows(t, result)
+	if len(rows) != 3 || string(rows[1][0]) != "''" || string(rows[1][1]) != "NULL" || string(rows[2][1]) != "30" {
+		t.Fatalf("expected empty fields to be '' in text columns and NULL elsewhere, got %q", rows)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestClassifyStatement(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want db.StatementKind
+	}{
+		{"SELECT 1", db.RowReturning},
+		{"  select\n* from t", db.RowReturning},
+		{"(SELECT 1) UNION (SELECT 2)", db.RowReturning},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", db.RowReturning},
+		{"WITH x AS (SELECT id FROM t) DELETE FROM t WHERE id IN (SELECT id FROM x)", db.DML},
+		{"WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x RETURNING id", db.RowReturning},
+		{"PRAGMA table_info(t)", db.RowReturning},
+		{"PRAGMA foreign_keys = ON", db.OtherStatement},
+		{"EXPLAIN QUERY PLAN SELECT 1", db.RowReturning},
+		{"VALUES (1), (2)", db.RowReturning},
+		{"SHOW TABLES", db.RowReturning},
+		{"EXEC sp_who", db.RowReturning},
+		{"INSERT INTO t (a) VALUES ('select me')", db.DML},
+		{"INSERT INTO t (a) VALUES (1) RETURNING id", db.RowReturning},
+		{"UPDATE t SET a = (SELECT 1)", db.DML},
+		{"-- SELECT\nDELETE FROM t", db.DML},
+		{"/* select */ CREATE TABLE t (id int)", db.DDL},
+		{"CREATE VIEW v AS SELECT 1", db.DDL},
+		{"DROP INDEX ix", db.DDL},
+		{"ALTER TABLE t ADD COLUMN b int", db.DDL},
+		{"BEGIN", db.TransactionControl},
+		{"COMMIT", db.TransactionControl},
+		{"ROLLBACK TO SAVEPOINT a", db.TransactionControl},
+		{"GRANT SELECT ON t TO bob", db.OtherStatement},
+		{"", db.OtherStatement},
+	}
+
+	for _, tt := range tests {
+		got := db.ClassifyStatement("", tt.stmt)
+		if got != tt.want {
+			t.Fatalf("%q: expected kind %d, got %d", tt.stmt, tt.want, got)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := db.Tokenize("", "SELECT 'a''b', \"c\", x::int, $1, :name, ? -- done")
+
+	want := []struct {
+		typ  db.TokenType
+		text string
+	}{
+		{db.WordToken, "SELECT"},
+		{db.StringToken, "'a''b'"},
+		{db.PunctToken, ","},
+		{db.QuotedIdentToken, "\"c\""},
+		{db.PunctToken, ","},
+		{db.WordToken, "x"},
+		{db.PunctToken, "::"},
+		{db.WordToken, "int"},
+		{db.PunctToken, ","},
+		{db.ParamToken, "$1"},
+		{db.PunctToken, ","},
+		{db.ParamToken, ":name"},
+		{db.PunctToken, ","},
+		{db.ParamToken, "?"},
+		{db.CommentToken, "-- done"},
+	}
+
+	if len(tokens) != len(want) {
+		t.Fatalf("expected %d tokens, got %d %+v", len(want), len(tokens), tokens)
+	}
+
+	for i, v := range want {
+		if tokens[i].Type != v.typ || tokens[i].Text != v.text {
+			t.Fatalf("token %d: expected %q (%d), got %q (%d)", i, v.text, v.typ, tokens[i].Text, tokens[i].Type)
+		}
+	}
+}
+
+func TestTokenizeDriver(t *testing.T) {
+	script := "SELECT arr[1], doc ?| array['a;b'] FROM t WHERE doc ? 'k'; SELECT [x] FROM t"
+
+	if got := db.SplitStatements("postgres", script); len(got) != 2 || got[0] != "SELECT arr[1], doc ?| array['a;b'] FROM t WHERE doc ? 'k'" {
+		t.Fatalf("unexpected postgres statements %q", got)
+	}
+
+	for _, v := range db.Tokenize("postgres", script) {
+		if v.Type == db.ParamToken || v.Type == db.QuotedIdentToken {
+			t.Fatalf("unexpected %q (%d) in postgres tokens", v.Text, v.Type)
+		}
+	}
+
+	want := map[string]db.TokenType{"[x]": db.QuotedIdentToken, "?": db.ParamToken}
+	for _, v := range db.Tokenize("sqlserver", "SELECT [x] FROM t WHERE a = ?") {
+		if typ, ok := want[v.Text]; ok && typ != v.Type {
+			t.Fatalf("expected %q to be %d for sqlserver, got %d", v.Text, typ, v.Type)
+		}
+		delete(want, v.Text)
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing sqlserver tokens %v", want)
+	}
+
+	mysql := `SELECT 'it\'s; ok', "a\"; b", 'c\\'; SELECT 2`
+	if got := db.SplitStatements("mysql", mysql); len(got) != 2 || got[1] != "SELECT 2" {
+		t.Fatalf("unexpected mysql statements %q", got)
+	}
+	if got := db.SplitStatements("sqlite3", `SELECT 'a\'; SELECT 2`); len(got) != 2 || got[0] != `SELECT 'a\'` {
+		t.Fatalf("backslashes shouldn't escape quotes outside of mysql %q", got)
+	}
+
+	if kind := db.ClassifyStatement("postgres", "SELECT tags[1] FROM t WHERE doc ?& array['a']"); kind != db.RowReturning {
+		t.Fatalf("expected a postgres SELECT with operators to return rows, got %d", kind)
+	}
+}
@@ -1,9 +1,26 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/sleepy-day/sqline/app"
 )
 
 func main() {
+	if len(os.Args) > 1 && (os.Args[1] == "diff" || os.Args[1] == "dump") {
+		run := app.RunDiff
+		if os.Args[1] == "dump" {
+			run = app.RunDump
+		}
+
+		err := run(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app.Run()
 }
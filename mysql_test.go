@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/sleepy-day/sqline/db"
+)
+
+// startMySQL makes a throwaway database for each name on the server in
+// SQLINE_MYSQL_DSN and connects to them, the databases are dropped when the
+// test ends.
+func startMySQL(t *testing.T, names ...string) []*db.MySQL {
+	t.Helper()
+
+	dsn := os.Getenv("SQLINE_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("SQLINE_MYSQL_DSN not set, skipping MySQL tests")
+	}
+
+	cfg, err := gomysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.MultiStatements = true
+
+	server, err := db.CreateMySQL(cfg.FormatDSN(), nil, nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	var conns []*db.MySQL
+	for _, name := range names {
+		name = fmt.Sprintf("sqline_%s_%d", name, time.Now().UnixNano())
+		_, err = server.Exec(context.Background(), "CREATE DATABASE "+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { server.Exec(context.Background(), "DROP DATABASE "+name) })
+
+		cfg.DBName = name
+		conn, err := db.CreateMySQL(cfg.FormatDSN(), nil, nil)
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+
+		conns = append(conns, conn)
+	}
+
+	return conns
+}
+
+func TestMySQLDump(t *testing.T) {
+	conns := startMySQL(t, "source", "target")
+	source, target := conns[0], conns[1]
+	ctx := context.Background()
+
+	_, err := source.Exec(ctx, `
+		CREATE TABLE authors (
+			id int NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			name varchar(50) NOT NULL DEFAULT 'it''s',
+			updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			KEY authors_name (name)
+		);
+		CREATE TABLE books (id int PRIMARY KEY, author_id int, FOREIGN KEY (author_id) REFERENCES authors (id));
+		INSERT INTO authors (name) VALUES ('Le Guin'), ('Pratchett'), ('Banks');
+		DELETE FROM authors WHERE id = 3;
+		INSERT INTO books VALUES (1, 2);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = db.Dump(ctx, &buf, source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := buf.String()
+
+	_, err = target.Exec(ctx, script)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, script)
+	}
+
+	_, err = target.Exec(ctx, "INSERT INTO authors () VALUES ()")
+	if err != nil {
+		t.Fatalf("inserting after the restore failed: %v\n%s", err, script)
+	}
+
+	rs, err := target.Select(ctx, "SELECT id, name, updated IS NOT NULL FROM authors ORDER BY id DESC LIMIT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readRows(t, rs)
+	if len(rows) != 2 || string(rows[1][0]) != "4" || string(rows[1][1]) != "it's" || string(rows[1][2]) != "1" {
+		t.Fatalf("the new row didn't get the table's defaults %q\n%s", rows, script)
+	}
+
+	tables, err := target.GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range tables {
+		if v.Name == "authors" && !strings.Contains(strings.ToUpper(v.Definition), "ON UPDATE CURRENT_TIMESTAMP") {
+			t.Fatalf("ON UPDATE was lost in the restore %q\n%s", v.Definition, script)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+// driverOnly reports a different driver so the placeholder styles of the
+// other databases can be checked without a server.
+type driverOnly struct {
+	db.Database
+	driver string
+}
+
+func (d driverOnly) Info() (string, string) {
+	return d.driver, ""
+}
+
+func TestScriptParams(t *testing.T) {
+	lite := createSchema(t, "SELECT 1")
+
+	tests := []struct {
+		driver string
+		script string
+		want   string
+	}{
+		{"sqlite3", "SELECT ?, ':no', :name, @other, :name; SELECT ? -- ?", "[?1 :name @other ?2]"},
+		{"sqlite3", "SELECT $name, ?3, ?, ?3; SELECT ?1, $name", "[$name ?3 ?4 ?1]"},
+		{"mysql", "SELECT * FROM t WHERE a = ? AND b = :c AND d = @e; UPDATE t SET a = ?", "[?1 ?2]"},
+		{"postgres", "SELECT $2::int, $1, data ? 'key', x::text FROM t; DELETE FROM t WHERE id = $1", "[$2 $1]"},
+		{"sqlserver", "DECLARE @a int, @b int = 2 SELECT @a, @b, @@ROWCOUNT, @id, @ID", "[@id]"},
+		{"sqlserver", "CREATE OR ALTER PROCEDURE p @id int AS SELECT @id", "[]"},
+	}
+
+	for _, tt := range tests {
+		got := fmt.Sprint(db.ScriptParams(driverOnly{Database: lite, driver: tt.driver}, tt.script))
+		if got != tt.want {
+			t.Errorf("%s %q: expected params %s, got %s", tt.driver, tt.script, tt.want, got)
+		}
+	}
+}
+
+func TestRunWithBinds(t *testing.T) {
+	lite := createSchema(t, "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, note TEXT)")
+	ctx := context.Background()
+
+	script := "INSERT INTO t VALUES (?, :name, ?); INSERT INTO t (id, name) VALUES (?, :name)"
+	if got := fmt.Sprint(db.ScriptParams(lite, script)); got != "[?1 :name ?2 ?3]" {
+		t.Fatalf("unexpected params %s", got)
+	}
+
+	one, two, name := "1", "2", "it's ?"
+	results := db.RunScript(ctx, lite, script, false, db.Binds{
+		"?1":    &one,
+		":name": &name,
+		"?2":    nil,
+		"?3":    &two,
+	})
+	for _, v := range results {
+		if v.Err != nil {
+			t.Fatal(v.Err)
+		}
+	}
+
+	results = db.RunScript(ctx, lite, "SELECT id, name, note FROM t WHERE id >= ? ORDER BY id", false, db.Binds{})
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+
+	rows := readRows(t, results[0].Rows)
+	if len(rows) != 1 {
+		t.Fatalf("expected a missing bind to match nothing as NULL, got %q", rows)
+	}
+
+	results = db.RunScript(ctx, lite, "SELECT id, name, note FROM t WHERE id >= ? ORDER BY id", false, db.Binds{"?1": &one})
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+
+	rows = readRows(t, results[0].Rows)
+	if len(rows) != 3 || string(rows[1][1]) != name || string(rows[1][2]) != "NULL" || string(rows[2][0]) != "2" || string(rows[2][1]) != name {
+		t.Fatalf("unexpected rows after binding %q", rows)
+	}
+}
+
+func TestRunWithSqliteBinds(t *testing.T) {
+	lite := createSchema(t, "SELECT 1")
+
+	a, b, c := "a", "b", "c"
+	results := db.RunScript(context.Background(), lite, "SELECT ?2, $name, ?1, ?, $name", false, db.Binds{
+		"?1":    &a,
+		"?2":    &b,
+		"$name": &c,
+		"?3":    nil,
+	})
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+
+	rows := readRows(t, results[0].Rows)
+	var got []string
+	for _, v := range rows[1] {
+		got = append(got, string(v))
+	}
+	if fmt.Sprint(got) != "[b c a NULL c]" {
+		t.Fatalf("unexpected values after binding %q", got)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestSqlitePlan(t *testing.T) {
+	lite, err := db.CreateSqlite(":memory:", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	_, err = lite.Exec(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = lite.Exec(ctx, "CREATE INDEX users_email ON users (email)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := lite.Explain(ctx, "SELECT * FROM users WHERE name = 'a';")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Children) != 1 || !plan.Children[0].FullScan || plan.Children[0].Relation != "users" {
+		t.Fatalf("expected a full scan of users, got %+v", plan.Children)
+	}
+	if !strings.Contains(plan.Children[0].Summary(), "FULL SCAN") {
+		t.Fatalf("expected the summary to flag the full scan, got %s", plan.Children[0].Summary())
+	}
+
+	plan, err = lite.Explain(ctx, "SELECT * FROM users WHERE email = 'a'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Children) != 1 || plan.Children[0].FullScan || plan.Children[0].Index != "users_email" {
+		t.Fatalf("expected a search using users_email, got %+v", plan.Children)
+	}
+
+	plan, err = lite.Explain(ctx, "SELECT * FROM users u JOIN users v ON v.id = u.id ORDER BY u.name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Children) < 2 || plan.Children[1].Index != "INTEGER PRIMARY KEY" {
+		t.Fatalf("expected the join to use the primary key, got %+v", plan.Children)
+	}
+
+	_, err = lite.Explain(ctx, "SELECT 1; SELECT 2")
+	if err != db.ErrExplainStatement {
+		t.Fatalf("expected ErrExplainStatement, got %v", err)
+	}
+}
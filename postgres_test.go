@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+// startPostgres runs a throwaway Postgres server out of a temp dir for the
+// length of the test, the initdb and pg_ctl binaries are looked up on PATH
+// or in SQLINE_PG_BIN if it's set.
+func startPostgres(t *testing.T) string {
+	t.Helper()
+
+	initdb, pgCtl := "initdb", "pg_ctl"
+	if binDir := os.Getenv("SQLINE_PG_BIN"); binDir != "" {
+		initdb = filepath.Join(binDir, initdb)
+		pgCtl = filepath.Join(binDir, pgCtl)
+	}
+
+	if _, err := exec.LookPath(initdb); err != nil {
+		t.Skip("initdb not found, skipping Postgres tests")
+	}
+	if _, err := exec.LookPath(pgCtl); err != nil {
+		t.Skip("pg_ctl not found, skipping Postgres tests")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("initdb can't be run as root, skipping Postgres tests")
+	}
+
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+
+	out, err := exec.Command(initdb, "-D", dataDir, "-U", "postgres", "--auth=trust", "-E", "UTF8").CombinedOutput()
+	if err != nil {
+		t.Fatalf("initdb failed: %v\n%s", err, out)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1", port, dir)
+	out, err = exec.Command(pgCtl, "-D", dataDir, "-o", opts, "-l", filepath.Join(dir, "log"), "-w", "start").CombinedOutput()
+	if err != nil {
+		t.Fatalf("pg_ctl start failed: %v\n%s", err, out)
+	}
+
+	t.Cleanup(func() {
+		exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "stop").Run()
+	})
+
+	return fmt.Sprintf("host=127.0.0.1 port=%d user=postgres dbname=postgres sslmode=disable", port)
+}
+
+func TestPostgres(t *testing.T) {
+	connStr := startPostgres(t)
+
+	var data [][][]rune
+	var resultMsg []rune
+	var updatedTables []db.Table
+	tableFunc := func(source *db.ResultSet, msg []rune) {
+		data = readRows(t, source)
+		resultMsg = msg
+	}
+	updateFunc := func(tables []db.Table) {
+		updatedTables = tables
+	}
+
+	psql, err := db.CreatePg(connStr, tableFunc, updateFunc)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	ctx := context.Background()
+	exec := psql.GetExecSQLFunc()
+
+	err = exec(ctx, []rune("CREATE TABLE authors (id serial PRIMARY KEY, name text NOT NULL DEFAULT 'anon')"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exec(ctx, []rune("CREATE TABLE books (id int PRIMARY KEY, author_id int REFERENCES authors(id) ON DELETE CASCADE, title text)"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exec(ctx, []rune("CREATE INDEX books_author_title ON books (author_id, title)"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updatedTables) != 2 {
+		t.Fatalf("expected tree update with 2 tables after DDL, got %d", len(updatedTables))
+	}
+
+	err = exec(ctx, []rune("INSERT INTO authors (name) VALUES ('Le Guin'), ('Pratchett')"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resultMsg) != "2 rows affected" {
+		t.Fatalf("unexpected result message %q", string(resultMsg))
+	}
+
+	err = exec(ctx, []rune("SELECT id, name FROM authors ORDER BY id"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d rows", len(data))
+	}
+	if string(data[0][1]) != "name" || string(data[2][1]) != "Pratchett" {
+		t.Fatalf("unexpected select result %q", data)
+	}
+
+	tables, err := psql.GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var books *db.Table
+	for i := range tables {
+		if tables[i].Name == "books" {
+			books = &tables[i]
+		}
+	}
+	if books == nil {
+		t.Fatalf("books table missing from %v", tables)
+	}
+
+	if len(books.Columns) != 3 || !books.Columns[0].PrimaryKey {
+		t.Fatalf("unexpected books columns %+v", books.Columns)
+	}
+
+	if len(books.ForeignKeys) != 1 {
+		t.Fatalf("expected 1 foreign key on books, got %+v", books.ForeignKeys)
+	}
+	fk := books.ForeignKeys[0]
+	if fk.RefTable != "authors" || len(fk.Columns) != 1 || fk.Columns[0] != "author_id" || fk.RefColumns[0] != "id" {
+		t.Fatalf("expected author_id to reference authors.id, got %+v", fk)
+	}
+	if fk.OnDelete != "CASCADE" {
+		t.Fatalf("expected ON DELETE CASCADE, got %q", fk.OnDelete)
+	}
+
+	var index *db.Index
+	for i := range books.Indexes {
+		if books.Indexes[i].Name == "books_author_title" {
+			index = &books.Indexes[i]
+		}
+	}
+	if index == nil || len(index.Cols) != 2 {
+		t.Fatalf("books_author_title missing or incomplete in %+v", books.Indexes)
+	}
+	if index.Cols[0].ColumnName != "author_id" || index.Cols[1].ColumnName != "title" {
+		t.Fatalf("index columns out of order %+v", index.Cols)
+	}
+	if index.Definition != "CREATE INDEX books_author_title ON public.books USING btree (author_id, title)" {
+		t.Fatalf("unexpected index definition %q", index.Definition)
+	}
+
+	if !strings.HasPrefix(books.Definition, `CREATE TABLE "books" (`) || !strings.Contains(books.Definition, "FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE CASCADE") {
+		t.Fatalf("unexpected books definition %q", books.Definition)
+	}
+
+	dbs, err := psql.GetDatabases()
+	if err != nil || len(dbs) == 0 {
+		t.Fatalf("failed to list databases: %v", err)
+	}
+
+	schemas, err := psql.GetSchemas()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foundPublic := false
+	for _, v := range schemas {
+		if v.Name == "public" {
+			foundPublic = true
+		}
+	}
+	if !foundPublic {
+		t.Fatalf("public schema missing from %+v", schemas)
+	}
+}
+
+func TestPostgresDump(t *testing.T) {
+	connStr := startPostgres(t)
+	ctx := context.Background()
+
+	source, err := db.CreatePg(connStr, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	_, err = source.Exec(ctx, `
+		CREATE TABLE authors (id serial PRIMARY KEY, name text NOT NULL);
+		CREATE TABLE books (id int GENERATED ALWAYS AS IDENTITY PRIMARY KEY, author_id int REFERENCES authors (id), title text);
+		CREATE TABLE tags (id bigint GENERATED BY DEFAULT AS IDENTITY, name text);
+		CREATE SEQUENCE tickets START WITH 100;
+		INSERT INTO authors (name) VALUES ('Le Guin'), ('Pratchett'), ('Banks');
+		DELETE FROM authors WHERE id = 3;
+		INSERT INTO books (author_id, title) VALUES (1, 'The Dispossessed'), (2, 'Mort');
+		INSERT INTO tags (name) VALUES ('sf');
+		SELECT nextval('tickets');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = db.Dump(ctx, &buf, source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := buf.String()
+
+	_, err = source.Exec(ctx, "CREATE DATABASE restored")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := db.CreatePg(strings.Replace(connStr, "dbname=postgres", "dbname=restored", 1), nil, nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer target.Close()
+
+	_, err = target.Exec(ctx, script)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, script)
+	}
+
+	_, err = target.Exec(ctx, `
+		INSERT INTO authors (name) VALUES ('Jemisin');
+		INSERT INTO books (author_id, title) VALUES (4, 'The Fifth Season');
+		INSERT INTO tags (name) VALUES ('fantasy');
+	`)
+	if err != nil {
+		t.Fatalf("inserting after the restore failed: %v\n%s", err, script)
+	}
+
+	for query, want := range map[string]string{
+		"SELECT max(id) FROM authors":  "4",
+		"SELECT max(id) FROM books":    "3",
+		"SELECT max(id) FROM tags":     "2",
+		"SELECT nextval('tickets')":    "101",
+		"SELECT count(*) FROM authors": "3",
+	} {
+		rs, err := target.Select(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows := readRows(t, rs)
+		if len(rows) != 2 || string(rows[1][0]) != want {
+			t.Fatalf("%s returned %q after the restore, expected %s\n%s", query, rows, want, script)
+		}
+	}
+
+	_, err = target.Exec(ctx, "INSERT INTO books (id, title) VALUES (10, 'x')")
+	if err == nil {
+		t.Fatalf("books.id should still be GENERATED ALWAYS after the restore\n%s", script)
+	}
+}
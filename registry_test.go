@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestDriverRegistry(t *testing.T) {
+	for _, name := range []string{"postgres", "sqlite3", "mysql", "sqlserver"} {
+		driver, ok := db.GetDriver(name)
+		if !ok {
+			t.Fatalf("driver %s isn't registered", name)
+		}
+		if driver.Label == "" || driver.ConnStrTemplate == "" || driver.Create == nil {
+			t.Fatalf("driver %s is missing fields %+v", name, driver)
+		}
+	}
+
+	if len(db.Drivers()) != 4 {
+		t.Fatalf("expected 4 drivers, got %d", len(db.Drivers()))
+	}
+
+	_, err := db.Open("oracle", "", nil, nil)
+	if !errors.Is(err, db.ErrUnknownDriver) {
+		t.Fatalf("expected ErrUnknownDriver, got %v", err)
+	}
+
+	database, err := db.Open("sqlite3", ":memory:", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if driver, _ := database.Info(); driver != "sqlite3" {
+		t.Fatalf("expected sqlite3 database, got %s", driver)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/db"
+)
+
+var _ components.RowSource = (*db.ResultSet)(nil)
+
+// readRows reads everything left in source as text, the header is the first
+// row.
+func readRows(t *testing.T, source *db.ResultSet) [][][]rune {
+	t.Helper()
+
+	if source == nil {
+		return nil
+	}
+
+	rows, err := source.Next(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header [][]rune
+	for _, v := range source.Columns() {
+		header = append(header, []rune(v.Name))
+	}
+
+	table := [][][]rune{header}
+	for _, row := range rows {
+		var cells [][]rune
+		for _, v := range row {
+			cells = append(cells, []rune(v.String()))
+		}
+		table = append(table, cells)
+	}
+
+	return table
+}
+
+func TestResultSetPaging(t *testing.T) {
+	connStr := filepath.Join(t.TempDir(), "paging.db")
+	lite, err := db.CreateSqlite(connStr, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	_, err = lite.Exec(ctx, `
+		CREATE TABLE nums AS
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 1000)
+		SELECT i FROM n
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := lite.Select(ctx, "SELECT i FROM nums ORDER BY i")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rs.Columns()[0].Name != "i" {
+		t.Fatalf("unexpected columns %+v", rs.Columns())
+	}
+
+	for page := 0; page < 3; page++ {
+		rows, err := rs.Next(300)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 300 {
+			t.Fatalf("page %d: expected 300 rows, got %d", page, len(rows))
+		}
+		if rows[0][0].Raw != []int64{1, 301, 601}[page] {
+			t.Fatalf("page %d starts at %s", page, rows[0][0])
+		}
+	}
+
+	if rs.Done() || rs.Fetched() != 900 {
+		t.Fatalf("expected 900 rows fetched and more to come, got %d", rs.Fetched())
+	}
+
+	rows, err := rs.Next(300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 100 || !rs.Done() {
+		t.Fatalf("expected the last 100 rows, got %d", len(rows))
+	}
+	if count, done := rs.Count(); count != 1000 || !done {
+		t.Fatalf("expected 1000 rows counted to the end, got %d (%v)", count, done)
+	}
+
+	rs, err = lite.Select(ctx, "SELECT i FROM nums")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs.Next(10)
+	rs.Close()
+
+	rows, err = rs.Next(10)
+	if len(rows) != 0 || !rs.Done() || rs.Fetched() != 10 {
+		t.Fatalf("expected nothing after Close, got %d rows (%v)", len(rows), err)
+	}
+	if count, done := rs.Count(); count != 10 || done {
+		t.Fatalf("expected a closed cursor to count 10 rows without reaching the end, got %d (%v)", count, done)
+	}
+}
+
+func TestResultSetTypes(t *testing.T) {
+	lite, err := db.CreateSqlite(":memory:", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	_, err = lite.Exec(ctx, `
+		CREATE TABLE vals (id INTEGER NOT NULL, price REAL, name TEXT, data BLOB);
+		INSERT INTO vals VALUES (1, 2.5, 'NULL', x'cafe'), (2, NULL, NULL, NULL);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := lite.Select(ctx, "SELECT id, price, name, data FROM vals ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cols := rs.Columns()
+	if cols[0].DatabaseType != "INTEGER" || cols[2].DatabaseType != "TEXT" {
+		t.Fatalf("unexpected column types %+v", cols)
+	}
+
+	rows, err := rs.Next(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := rows[0]
+	if !first[0].IsNumber() || !first[1].IsNumber() || first[1].String() != "2.5" {
+		t.Fatalf("expected numbers, got %+v", first)
+	}
+	if first[2].IsNull() || first[2].Kind != db.TextValue || first[2].String() != "NULL" {
+		t.Fatalf("the text 'NULL' was read as %+v", first[2])
+	}
+	if first[3].Kind != db.BytesValue || first[3].String() != "0xcafe" {
+		t.Fatalf("unexpected blob %+v", first[3])
+	}
+
+	for i, v := range rows[1][1:] {
+		if !v.IsNull() {
+			t.Fatalf("column %d: expected NULL, got %+v", i+1, v)
+		}
+	}
+}
+
+func TestResultSetRows(t *testing.T) {
+	columns := []db.ColumnInfo{{Name: "id"}, {Name: "note"}}
+	rs := db.NewStaticResultSet(columns, []db.Row{
+		{db.Value{Kind: db.NumberValue, Raw: int64(1)}, db.Value{}},
+		{db.Value{Kind: db.NumberValue, Raw: int64(2)}, db.TextVal("NULL")},
+	})
+
+	if names := rs.ColumnNames(); len(names) != 2 || names[0] != "id" || names[1] != "note" {
+		t.Fatalf("unexpected column names %q", names)
+	}
+
+	rows, err := rs.NextRows(0)
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d (%v)", len(rows), err)
+	}
+
+	switch {
+	case rows[0].Len() != 2 || rows[0].Text(0) != "1" || !rows[0].IsNumber(0):
+		t.Fatal("expected the id to be shown as a number")
+	case !rows[0].IsNull(1) || rows[1].IsNull(1) || rows[1].Text(1) != "NULL":
+		t.Fatal("expected only the real NULL to be reported as NULL")
+	}
+}
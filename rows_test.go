@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestDeleteRows(t *testing.T) {
+	lite := createSchema(t, `
+		CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE pairs (a INTEGER, b TEXT, PRIMARY KEY (a, b));
+		INSERT INTO people VALUES (1, 'Ann'), (2, 'Bob'), (3, 'Cat');
+		INSERT INTO pairs VALUES (1, 'x'), (1, 'y'), (2, 'x');
+	`)
+	ctx := context.Background()
+	tables := getTables(t, lite)
+
+	tests := []struct {
+		stmt     string
+		rows     []int
+		expected string
+		check    string
+		left     int
+	}{
+		{"SELECT name, id FROM people ORDER BY id", []int{0, 2}, `DELETE FROM "people" WHERE "id" IN (?, ?);` + "\n-- values: 1, 3", "SELECT * FROM people", 1},
+		{"SELECT * FROM pairs ORDER BY a, b", []int{1, 2}, `DELETE FROM "pairs" WHERE ("a" = ? AND "b" = ?) OR ("a" = ? AND "b" = ?);` + "\n-- values: 1, 'y', 2, 'x'", "SELECT * FROM pairs", 1},
+	}
+
+	for _, tt := range tests {
+		rs := selectResult(t, lite, tt.stmt)
+		rows, err := rs.Next(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		target, err := db.FindEditTarget("sqlite3", rs.Statement(), tables, rs.Columns())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var selected []db.Row
+		for _, v := range tt.rows {
+			selected = append(selected, rows[v])
+		}
+
+		stmt, err := target.Delete("sqlite3", selected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stmt.String() != tt.expected {
+			t.Fatalf("%q: unexpected statement %s", tt.stmt, stmt)
+		}
+
+		affected, err := stmt.Exec(ctx, lite)
+		if err != nil || affected != int64(len(tt.rows)) {
+			t.Fatalf("%q: expected %d rows deleted, got %d (%v)", tt.stmt, len(tt.rows), affected, err)
+		}
+
+		if got := readRows(t, selectResult(t, lite, tt.check)); len(got)-1 != tt.left {
+			t.Fatalf("%q: expected %d rows left, got %q", tt.stmt, tt.left, got)
+		}
+	}
+
+	if _, err := (&db.EditTarget{Keys: []int{0}}).Delete("sqlite3", nil); err == nil {
+		t.Fatal("expected an error deleting no rows")
+	}
+}
+
+func TestInsertRow(t *testing.T) {
+	lite := createSchema(t, `
+		CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL, qty INTEGER DEFAULT 5, note TEXT);
+	`)
+	ctx := context.Background()
+
+	var items db.Table
+	for _, v := range getTables(t, lite) {
+		if v.Name == "items" {
+			items = v
+		}
+	}
+
+	name := "it's"
+	stmt := db.InsertRow("sqlite3", items, map[string]*string{"name": &name, "note": nil})
+	if stmt.String() != `INSERT INTO "items" ("name", "note") VALUES (?, ?);`+"\n-- values: 'it''s', NULL" {
+		t.Fatalf("unexpected statement %s", stmt)
+	}
+	if _, err := stmt.Exec(ctx, lite); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readRows(t, selectResult(t, lite, "SELECT name, qty, note FROM items"))
+	if len(got) != 2 || string(got[1][0]) != name || string(got[1][1]) != "5" || string(got[1][2]) != "NULL" {
+		t.Fatalf("unexpected rows after inserting %q", got)
+	}
+
+	if _, err := db.InsertRow("sqlite3", items, nil).Exec(ctx, lite); err == nil {
+		t.Fatal("expected the NOT NULL name to be refused")
+	}
+
+	if sql := db.InsertRow("mysql", items, nil).SQL; sql != "INSERT INTO `items` () VALUES ()" {
+		t.Fatalf("unexpected mysql statement %s", sql)
+	}
+
+	if sql := db.InsertRow("postgres", db.Table{Name: "app.items", Columns: items.Columns}, map[string]*string{"id": &name, "qty": &name}).SQL; sql != `INSERT INTO "app"."items" ("id", "qty") VALUES ($1, $2)` {
+		t.Fatalf("unexpected postgres statement %s", sql)
+	}
+}
+
+func TestDeleteRowsSchemas(t *testing.T) {
+	users := func(name string) db.Table {
+		return db.Table{Name: name, Type: db.TableObject, Columns: []db.Column{
+			{Name: "id", Type: "integer", PrimaryKey: true},
+			{Name: "name", Type: "text"},
+		}}
+	}
+	tables := []db.Table{users("users"), users("other.users")}
+	columns := []db.ColumnInfo{{Name: "id"}, {Name: "name"}}
+	rows := []db.Row{{db.IntVal(7), db.TextVal("Ann")}}
+
+	for stmt, expected := range map[string]string{
+		"SELECT * FROM other.users":  `DELETE FROM "other"."users" WHERE "id" IN ($1);`,
+		"SELECT * FROM public.users": `DELETE FROM "users" WHERE "id" IN ($1);`,
+		"SELECT * FROM users":        `DELETE FROM "users" WHERE "id" IN ($1);`,
+	} {
+		target, err := db.FindEditTarget("postgres", stmt, tables, columns)
+		if err != nil {
+			t.Fatalf("%q: %v", stmt, err)
+		}
+
+		del, err := target.Delete("postgres", rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if del.SQL+";" != expected {
+			t.Errorf("%q: expected %s, got %s", stmt, expected, del.SQL)
+		}
+	}
+
+	if _, err := db.FindEditTarget("postgres", "SELECT * FROM zzz.users", tables, columns); err == nil {
+		t.Fatal("expected a table in another schema not to be taken for public.users")
+	}
+}
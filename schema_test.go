@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestSqliteSchemaObjects(t *testing.T) {
+	lite, err := db.CreateSqlite(":memory:", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = lite.Exec(context.Background(), `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE INDEX users_name ON users (name);
+		CREATE VIEW user_names AS SELECT name FROM users;
+		CREATE TRIGGER users_ins AFTER INSERT ON users BEGIN SELECT 1; END;
+		CREATE VIRTUAL TABLE docs USING fts4(body);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := lite.GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]db.Table)
+	for _, v := range tables {
+		byName[v.Name] = v
+	}
+
+	if _, ok := byName["users_name"]; ok {
+		t.Fatal("index listed as a table")
+	}
+	if _, ok := byName["users_ins"]; ok {
+		t.Fatal("trigger listed as a table")
+	}
+
+	users := byName["users"]
+	if users.Type != db.TableObject || len(users.Columns) != 2 || len(users.Indexes) != 1 {
+		t.Fatalf("unexpected users table %+v", users)
+	}
+	if users.Definition != "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)" || users.Indexes[0].Definition != "CREATE INDEX users_name ON users (name)" {
+		t.Fatalf("expected the stored SQL for users and users_name, got %q and %q", users.Definition, users.Indexes[0].Definition)
+	}
+	if len(users.Triggers) != 1 || !strings.HasPrefix(users.Triggers[0].Definition, "CREATE TRIGGER users_ins") {
+		t.Fatalf("expected the users_ins trigger on users, got %+v", users.Triggers)
+	}
+
+	view := byName["user_names"]
+	if view.Type != db.ViewObject || !strings.HasPrefix(view.Definition, "CREATE VIEW user_names") {
+		t.Fatalf("unexpected view %+v", view)
+	}
+
+	docs := byName["docs"]
+	if docs.Type != db.VirtualTableObject || !strings.HasPrefix(docs.Definition, "CREATE VIRTUAL TABLE docs") {
+		t.Fatalf("unexpected virtual table %+v", docs)
+	}
+
+	triggers, err := lite.GetTriggers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(triggers) != 1 || triggers[0].TableName != "users" {
+		t.Fatalf("unexpected triggers %+v", triggers)
+	}
+}
+
+func TestSqliteForeignKeys(t *testing.T) {
+	lite, err := db.CreateSqlite(":memory:", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = lite.Exec(context.Background(), `
+		CREATE TABLE regions (country TEXT, code TEXT, PRIMARY KEY (country, code));
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE offices (
+			id INTEGER PRIMARY KEY,
+			country TEXT,
+			code TEXT,
+			manager INTEGER REFERENCES users ON DELETE SET NULL,
+			deputy INTEGER REFERENCES users (id),
+			FOREIGN KEY (country, code) REFERENCES regions (country, code) ON UPDATE CASCADE
+		);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := lite.GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var offices db.Table
+	for _, v := range tables {
+		if v.Name == "offices" {
+			offices = v
+		}
+	}
+
+	if len(offices.Columns) != 5 {
+		t.Fatalf("expected 5 columns on offices, got %+v", offices.Columns)
+	}
+	if len(offices.ForeignKeys) != 3 {
+		t.Fatalf("expected 3 foreign keys on offices, got %+v", offices.ForeignKeys)
+	}
+
+	for _, fk := range offices.ForeignKeys {
+		if fk.RefTable != "regions" {
+			continue
+		}
+		if strings.Join(fk.Columns, ",") != "country,code" || strings.Join(fk.RefColumns, ",") != "country,code" {
+			t.Fatalf("unexpected composite key %+v", fk)
+		}
+		if fk.OnUpdate != "CASCADE" {
+			t.Fatalf("expected ON UPDATE CASCADE, got %q", fk.OnUpdate)
+		}
+	}
+
+	refTable, refColumn, ok := offices.ColumnForeignKey("manager")
+	if !ok || refTable != "users" || refColumn != "id" {
+		t.Fatalf("expected manager to reference users.id, got %s.%s", refTable, refColumn)
+	}
+
+	if _, _, ok := offices.ColumnForeignKey("id"); ok {
+		t.Fatal("id isn't part of a foreign key")
+	}
+}
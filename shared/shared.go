@@ -9,3 +9,12 @@ func Assert(cond bool, msg string) {
 func Scale(factor float32, value int) int {
 	return int(factor * float32(value))
 }
+
+// Row is a row of values as the UI shows them, Text is a cell's display
+// text and the Is methods say how it should be styled.
+type Row interface {
+	Len() int
+	Text(col int) string
+	IsNull(col int) bool
+	IsNumber(col int) bool
+}
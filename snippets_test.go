@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sleepy-day/sqline/util"
+)
+
+func TestSnippets(t *testing.T) {
+	confDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", confDir)
+	t.Setenv("HOME", confDir)
+
+	userDir, err := os.UserConfigDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	err = os.MkdirAll(filepath.Join(userDir, "sqline"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &util.SqlineConf{
+		SavedConns: []util.DBEntry{
+			{Name: "local", Driver: "sqlite3", ConnStr: "local.db"},
+			{Name: "prod", Driver: "postgres", ConnStr: "postgres://prod"},
+		},
+	}
+
+	for _, v := range []struct {
+		conn    string
+		snippet util.Snippet
+	}{
+		{"", util.Snippet{Name: "tables", SQL: "SELECT name\nFROM sqlite_master;"}},
+		{"", util.Snippet{Name: "Count", SQL: "SELECT count(*) FROM t"}},
+		{"local", util.Snippet{Name: "users", SQL: "SELECT * FROM users"}},
+		{"local", util.Snippet{Name: " users ", SQL: "SELECT id FROM users"}},
+	} {
+		err = conf.AddSnippet(v.conn, v.snippet)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = conf.AddSnippet("nope", util.Snippet{Name: "x"}); err == nil {
+		t.Fatal("expected an error saving under a connection that isn't saved")
+	}
+	if err = conf.AddSnippet("", util.Snippet{Name: "  ", SQL: "SELECT 1"}); err != util.ErrSnippetName {
+		t.Fatalf("expected ErrSnippetName, got %v", err)
+	}
+
+	err = util.SaveConf(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := util.LoadConf()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippets := loaded.SnippetsFor("local")
+	if len(snippets) != 3 {
+		t.Fatalf("expected 3 snippets for local, got %+v", snippets)
+	}
+	if snippets[0].Connection != "local" || snippets[0].SQL != "SELECT id FROM users" {
+		t.Fatalf("expected the replaced connection snippet first, got %+v", snippets[0])
+	}
+	if snippets[1].Name != "Count" || snippets[2].Name != "tables" || snippets[2].SQL != "SELECT name\nFROM sqlite_master;" || snippets[2].Connection != "" {
+		t.Fatalf("unexpected global snippets %+v", snippets[1:])
+	}
+
+	if got := loaded.SnippetsFor("prod"); len(got) != 2 {
+		t.Fatalf("expected only the global snippets for prod, got %+v", got)
+	}
+	if got := loaded.SnippetsFor(""); len(got) != 2 {
+		t.Fatalf("expected only the global snippets without a connection, got %+v", got)
+	}
+}
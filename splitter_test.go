@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "simple",
+			script: "SELECT 1; SELECT 2;\n\n;SELECT 3",
+			want:   []string{"SELECT 1", "SELECT 2", "SELECT 3"},
+		},
+		{
+			name:   "quotes",
+			script: `INSERT INTO t VALUES ('a;b', 'it''s;'); SELECT "odd;name", ` + "`x;y`" + `, [z;w] FROM t`,
+			want:   []string{`INSERT INTO t VALUES ('a;b', 'it''s;')`, `SELECT "odd;name", ` + "`x;y`" + `, [z;w] FROM t`},
+		},
+		{
+			name:   "comments",
+			script: "SELECT 1; -- not; a statement\nSELECT /* still; one */ 2;",
+			want:   []string{"SELECT 1", "-- not; a statement\nSELECT /* still; one */ 2"},
+		},
+		{
+			name:   "dollar quotes",
+			script: "CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql; SELECT $$a;b$$, $1",
+			want:   []string{"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql", "SELECT $$a;b$$, $1"},
+		},
+		{
+			name: "trigger",
+			script: `CREATE TRIGGER t_ins AFTER INSERT ON t BEGIN
+	UPDATE t SET n = CASE WHEN n IS NULL THEN 0 ELSE n END;
+	INSERT INTO log VALUES (new.id);
+END;
+BEGIN;
+SELECT 1;
+COMMIT;`,
+			want: []string{
+				"CREATE TRIGGER t_ins AFTER INSERT ON t BEGIN\n\tUPDATE t SET n = CASE WHEN n IS NULL THEN 0 ELSE n END;\n\tINSERT INTO log VALUES (new.id);\nEND",
+				"BEGIN",
+				"SELECT 1",
+				"COMMIT",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := db.SplitStatements("", tt.script)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: expected %d statements, got %d %q", tt.name, len(tt.want), len(got), got)
+		}
+
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("%s: statement %d doesn't match\nexpected %q\ngot      %q", tt.name, i, tt.want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestStatementAt(t *testing.T) {
+	script := "SELECT 1;\nSELECT 'a;b' FROM t;\n\nUPDATE t SET x = 1\n"
+	tests := []struct {
+		offset int
+		want   string
+	}{
+		{0, "SELECT 1"},
+		{8, "SELECT 1"},
+		{9, "SELECT 1"},
+		{16, "SELECT 'a;b' FROM t"},
+		{33, "UPDATE t SET x = 1"},
+		{len([]rune(script)), "UPDATE t SET x = 1"},
+	}
+
+	for _, tt := range tests {
+		if got := db.StatementAt("", script, tt.offset); got != tt.want {
+			t.Fatalf("offset %d: expected %q, got %q", tt.offset, tt.want, got)
+		}
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	var data [][][]rune
+	tableFunc := func(source *db.ResultSet, msg []rune) {
+		data = readRows(t, source)
+	}
+
+	lite, err := db.CreateSqlite(":memory:", tableFunc, func([]db.Table) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	exec := lite.GetExecSQLFunc()
+	err = exec(ctx, []rune("CREATE TABLE t (id int); INSERT INTO t VALUES (1); INSERT INTO t VALUES (2);"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 4 {
+		t.Fatalf("expected a summary row per statement, got %q", data)
+	}
+
+	err = exec(ctx, []rune("INSERT INTO t VALUES (3); INSERT INTO missing VALUES (1); INSERT INTO t VALUES (4)"), nil)
+	if err == nil {
+		t.Fatal("expected error from missing table")
+	}
+	if len(data) != 3 {
+		t.Fatalf("expected script to stop at the failing statement, got %q", data)
+	}
+
+	lite.SetContinueOnError(true)
+	exec = lite.GetExecSQLFunc()
+	exec(ctx, []rune("INSERT INTO missing VALUES (1); INSERT INTO t VALUES (5)"), nil)
+
+	err = exec(ctx, []rune("SELECT count(*) FROM t"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[1][0]) != "4" {
+		t.Fatalf("expected 4 rows in t, got %s", string(data[1][0]))
+	}
+}
+
+func TestRunScriptCancel(t *testing.T) {
+	lite, err := db.CreateSqlite(":memory:", func(*db.ResultSet, []rune) {}, func([]db.Table) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	results := db.RunScript(ctx, lite, `
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n)
+		SELECT count(*) FROM n;
+		SELECT 1;
+	`, true, nil)
+
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("query wasn't interrupted by the context")
+	}
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected the script to stop with an error on the first statement, got %+v", results)
+	}
+}
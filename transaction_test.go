@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sleepy-day/sqline/db"
+)
+
+func TestTransaction(t *testing.T) {
+	connStr := filepath.Join(t.TempDir(), "tx.db")
+	lite, err := db.CreateSqlite(connStr, func(*db.ResultSet, []rune) {}, func([]db.Table) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	_, err = lite.Exec(ctx, "CREATE TABLE items (id INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := func() string {
+		t.Helper()
+
+		rs, err := lite.Select(ctx, "SELECT COUNT(*) FROM items")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(readRows(t, rs)[1][0])
+	}
+
+	err = lite.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Begin(); err != db.ErrTransactionOpen {
+		t.Fatalf("expected ErrTransactionOpen, got %v", err)
+	}
+
+	lite.Exec(ctx, "INSERT INTO items VALUES (1)")
+	lite.Exec(ctx, "INSERT INTO items VALUES (2)")
+	if status := lite.Transaction(); !status.Open || status.Statements != 2 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if got := count(); got != "2" {
+		t.Fatalf("expected 2 rows inside the transaction, got %s", got)
+	}
+
+	err = lite.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != "0" || lite.Transaction().Open {
+		t.Fatalf("expected the rollback to close the transaction and leave 0 rows, got %s", got)
+	}
+
+	exec := lite.GetExecSQLFunc()
+	err = exec(ctx, []rune("BEGIN; INSERT INTO items VALUES (1); SAVEPOINT s; INSERT INTO items VALUES (2); ROLLBACK TO s;"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lite.Transaction().Open {
+		t.Fatal("expected BEGIN from a script to open a transaction")
+	}
+
+	err = exec(ctx, []rune("COMMIT"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != "1" || lite.Transaction().Open {
+		t.Fatalf("expected COMMIT to close the transaction with 1 row, got %s", got)
+	}
+
+	lite.SetAutocommit(false)
+	_, err = lite.Exec(ctx, "DELETE FROM items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := lite.Transaction(); !status.Open || status.Statements != 1 {
+		t.Fatalf("expected a statement with autocommit off to open a transaction, got %+v", status)
+	}
+
+	err = lite.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lite.SetAutocommit(true)
+	if got := count(); got != "1" {
+		t.Fatalf("expected the delete to be rolled back, got %s rows", got)
+	}
+	if err := lite.Commit(); err != db.ErrNoTransaction {
+		t.Fatalf("expected ErrNoTransaction, got %v", err)
+	}
+}
+
+func TestTransactionKeepsResult(t *testing.T) {
+	connStr := filepath.Join(t.TempDir(), "tx.db")
+	lite, err := db.CreateSqlite(connStr, func(*db.ResultSet, []rune) {}, func([]db.Table) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	_, err = lite.Exec(ctx, `
+		CREATE TABLE items AS
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 12000)
+		SELECT i AS id FROM n`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lite.SetAutocommit(false)
+	defer lite.Rollback()
+
+	for _, tt := range []struct {
+		limit     int
+		truncated bool
+	}{
+		{500, false},
+		{12000, true},
+	} {
+		rs, err := lite.Select(ctx, "SELECT id FROM items WHERE id <= ?", tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = lite.GetTables(); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := rs.Next(0)
+		switch {
+		case err != nil:
+			t.Fatal(err)
+		case rs.Truncated() != tt.truncated:
+			t.Fatalf("%d rows: expected truncated to be %t", tt.limit, tt.truncated)
+		case !tt.truncated && len(rows) != tt.limit:
+			t.Fatalf("expected all %d rows to be kept, got %d", tt.limit, len(rows))
+		case tt.truncated && (len(rows) < 10000 || len(rows) >= tt.limit):
+			t.Fatalf("expected the rows read before the cut, got %d", len(rows))
+		}
+	}
+}
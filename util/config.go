@@ -12,13 +12,16 @@ import (
 )
 
 type DBEntry struct {
-	Name    string `toml:"name"`
-	Driver  string `toml:"driver"`
-	ConnStr string `toml:"conn_str"`
+	Name     string    `toml:"name"`
+	Driver   string    `toml:"driver"`
+	ConnStr  string    `toml:"conn_str"`
+	Snippets []Snippet `toml:"snippets,omitempty"`
 }
 
 type SqlineConf struct {
-	SavedConns []DBEntry `toml:"saved_conns"`
+	SavedConns      []DBEntry `toml:"saved_conns"`
+	ContinueOnError bool      `toml:"continue_on_error"`
+	Snippets        []Snippet `toml:"snippets,omitempty"`
 }
 
 func SaveConf(conf *SqlineConf) error {
@@ -0,0 +1,228 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// MaxHistory is how many of the most recent history entries are loaded.
+const MaxHistory = 5000
+
+// HistoryEntry is one statement that was run, Rows is -1 when the number of
+// rows returned or changed wasn't known and Error is empty if it succeeded.
+type HistoryEntry struct {
+	Connection string        `json:"connection"`
+	Statement  string        `json:"statement"`
+	RanAt      time.Time     `json:"ran_at"`
+	Duration   time.Duration `json:"duration"`
+	Rows       int64         `json:"rows"`
+	Error      string        `json:"error,omitempty"`
+}
+
+var historyMu sync.Mutex
+
+// HistoryPath is the history file in the sqline config dir, one JSON
+// encoded entry per line.
+func HistoryPath() (string, error) {
+	confDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(confDir, "sqline", "history.jsonl"), nil
+}
+
+// AppendHistory adds entries to the end of the history file at path,
+// creating it and its directory if needed.
+func AppendHistory(path string, entries ...HistoryEntry) error {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	for _, v := range entries {
+		err = encoder.Encode(v)
+		if err != nil {
+			break
+		}
+	}
+
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// UpdateHistory replaces the newest entry in the file at path that ran the
+// same statement on the same connection at the same time as entry, used to
+// fill in the row count once a SELECT's rows have all been read.
+func UpdateHistory(path string, entry HistoryEntry) error {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	lines := bytes.SplitAfter(buf, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var v HistoryEntry
+		if json.Unmarshal(lines[i], &v) != nil || v.Connection != entry.Connection || v.Statement != entry.Statement || !v.RanAt.Equal(entry.RanAt) {
+			continue
+		}
+
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		lines[i] = append(line, '\n')
+		return writeFile(path, bytes.Join(lines, nil))
+	}
+
+	return nil
+}
+
+// writeFile replaces the file at path with buf through a temporary file so
+// a failed write doesn't leave it half written.
+func writeFile(path string, buf []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(buf)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
+
+// LoadHistory reads the last MaxHistory entries from the file at path, oldest
+// first. A missing file is an empty history and lines that can't be read are
+// skipped.
+func LoadHistory(path string) ([]HistoryEntry, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var entry HistoryEntry
+			if json.Unmarshal(line, &entry) == nil {
+				entries = append(entries, entry)
+			}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if len(entries) > MaxHistory {
+		entries = entries[len(entries)-MaxHistory:]
+	}
+
+	return entries, nil
+}
+
+// FuzzyScore matches the characters of pattern in order anywhere in text
+// ignoring case, ok is false if they aren't all there. Matches that run
+// together or start a word score higher.
+func FuzzyScore(pattern, text string) (score int, ok bool) {
+	pat := []rune(strings.ToLower(pattern))
+	if len(pat) == 0 {
+		return 0, true
+	}
+
+	i, last := 0, -2
+	prev := ' '
+	for j, ch := range []rune(text) {
+		if i < len(pat) && unicode.ToLower(ch) == pat[i] {
+			score++
+			if last == j-1 {
+				score += 2
+			}
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+				score++
+			}
+
+			last = j
+			i++
+		}
+		prev = ch
+	}
+
+	return score, i == len(pat)
+}
+
+// SearchHistory returns the entries whose statement matches query, best
+// matches first and newest first between equal ones. An empty query returns
+// every entry newest first.
+func SearchHistory(entries []HistoryEntry, query string) []HistoryEntry {
+	type match struct {
+		entry HistoryEntry
+		score int
+		index int
+	}
+
+	var matches []match
+	for i, v := range entries {
+		score, ok := FuzzyScore(query, v.Statement)
+		if ok {
+			matches = append(matches, match{entry: v, score: score, index: i})
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b match) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return b.index - a.index
+	})
+
+	results := make([]HistoryEntry, len(matches))
+	for i, v := range matches {
+		results[i] = v.entry
+	}
+
+	return results
+}
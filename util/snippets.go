@@ -0,0 +1,93 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrSnippetName = errors.New("snippet name is empty")
+
+// Snippet is a named piece of SQL that can be inserted into the editor.
+type Snippet struct {
+	Name string `toml:"name"`
+	SQL  string `toml:"sql"`
+}
+
+// ScopedSnippet is a snippet along with the connection it's saved under,
+// Connection is empty for global snippets.
+type ScopedSnippet struct {
+	Snippet
+	Connection string
+}
+
+// SnippetsFor returns the snippets saved under the connection named conn
+// followed by the global ones, each sorted by name.
+func (conf *SqlineConf) SnippetsFor(conn string) []ScopedSnippet {
+	var snippets []ScopedSnippet
+	if entry := conf.entry(conn); entry != nil {
+		for _, v := range sortedSnippets(entry.Snippets) {
+			snippets = append(snippets, ScopedSnippet{Snippet: v, Connection: conn})
+		}
+	}
+
+	for _, v := range sortedSnippets(conf.Snippets) {
+		snippets = append(snippets, ScopedSnippet{Snippet: v})
+	}
+
+	return snippets
+}
+
+// AddSnippet saves snippet globally when conn is empty or under the saved
+// connection named conn, a snippet with the same name in that scope is
+// replaced.
+func (conf *SqlineConf) AddSnippet(conn string, snippet Snippet) error {
+	snippet.Name = strings.TrimSpace(snippet.Name)
+	if snippet.Name == "" {
+		return ErrSnippetName
+	}
+
+	snippets := &conf.Snippets
+	if conn != "" {
+		entry := conf.entry(conn)
+		if entry == nil {
+			return fmt.Errorf("no saved connection named %q", conn)
+		}
+		snippets = &entry.Snippets
+	}
+
+	i := slices.IndexFunc(*snippets, func(v Snippet) bool {
+		return v.Name == snippet.Name
+	})
+	if i >= 0 {
+		(*snippets)[i] = snippet
+	} else {
+		*snippets = append(*snippets, snippet)
+	}
+
+	return nil
+}
+
+func (conf *SqlineConf) entry(name string) *DBEntry {
+	if name == "" {
+		return nil
+	}
+
+	for i := range conf.SavedConns {
+		if conf.SavedConns[i].Name == name {
+			return &conf.SavedConns[i]
+		}
+	}
+
+	return nil
+}
+
+func sortedSnippets(snippets []Snippet) []Snippet {
+	sorted := slices.Clone(snippets)
+	slices.SortStableFunc(sorted, func(a, b Snippet) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return sorted
+}
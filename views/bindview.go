@@ -0,0 +1,91 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/db"
+)
+
+// BindFunc runs the pending script with the values from the form.
+type BindFunc func(binds db.Binds) error
+
+// BindView asks for a value for each bind parameter in a script.
+type BindView struct {
+	form     *fieldForm
+	infoBox  *comp.InfoBox
+	bindFunc BindFunc
+}
+
+func CreateBindView(left, top, right, bottom int, style *tcell.Style, bindFunc BindFunc) *BindView {
+	bv := &BindView{
+		bindFunc: bindFunc,
+		form:     createFieldForm(left, top, right, bottom, 3, "Bind Parameters", style),
+	}
+
+	inpLeft, inpTop, inpRight, inpBottom := bv.form.window.RequestRows(3)
+	bv.infoBox = comp.CreateInfoBox(inpLeft, inpTop, inpRight, inpBottom, style)
+
+	return bv
+}
+
+// SetParams builds a field for each of names, filled in from last where it
+// has a value for the name.
+func (bv *BindView) SetParams(names []string, last db.Binds) {
+	bv.form.clear()
+	for i, v := range names {
+		field := bv.form.addField(v, v)
+		if value, ok := last[v]; ok {
+			if value == nil {
+				field.null = true
+				bv.form.setLabel(i)
+			} else {
+				field.input.SetString(*value)
+			}
+		}
+	}
+
+	bv.infoBox.Reset()
+	bv.form.focus()
+}
+
+// Binds is the values from the form keyed by parameter name.
+func (bv *BindView) Binds() db.Binds {
+	binds := make(db.Binds)
+	for _, v := range bv.form.fields {
+		if v.null {
+			binds[v.name] = nil
+			continue
+		}
+
+		value := v.input.GetString()
+		binds[v.name] = &value
+	}
+
+	return binds
+}
+
+func (bv *BindView) Render(screen tcell.Screen) {
+	bv.form.render(screen)
+	bv.infoBox.Render(screen)
+}
+
+func (bv *BindView) HandleInput(key *tcell.EventKey) {
+	if key.Key() != tcell.KeyEnter {
+		bv.form.handleInput(key)
+		return
+	}
+
+	if len(bv.form.fields) == 0 {
+		return
+	}
+
+	err := bv.bindFunc(bv.Binds())
+	if err != nil {
+		bv.infoBox.SetMessage("Error: " + err.Error())
+	}
+}
+
+// Reset clears the message, the values are kept until the next SetParams.
+func (bv *BindView) Reset() {
+	bv.infoBox.Reset()
+}
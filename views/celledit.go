@@ -0,0 +1,132 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+)
+
+// PrepareEditFunc builds the statement that writes value back, a nil value
+// is NULL. The returned text is shown for confirmation.
+type PrepareEditFunc func(value *string) (string, error)
+
+// ConfirmFunc runs the statement that was shown for confirmation.
+type ConfirmFunc func() error
+
+// CellEditView edits a single cell from the data table, Enter shows the
+// UPDATE that will be run and Enter again runs it.
+type CellEditView struct {
+	confirming  bool
+	null        bool
+	window      *comp.Window
+	valueInput  *comp.TextBox
+	infoBox     *comp.InfoBox
+	sqlView     *comp.TextView
+	prepareFunc PrepareEditFunc
+	confirmFunc ConfirmFunc
+}
+
+func CreateCellEditView(left, top, right, bottom int, style *tcell.Style, prepareFunc PrepareEditFunc, confirmFunc ConfirmFunc) *CellEditView {
+	cv := &CellEditView{
+		prepareFunc: prepareFunc,
+		confirmFunc: confirmFunc,
+		sqlView:     comp.CreateTextView(left, top, right, bottom-3, style),
+	}
+
+	cv.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune("Edit Cell"), style)
+
+	inpLeft, inpTop, inpRight, _ := cv.window.RequestRows(4)
+	cv.valueInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("Value:"), style)
+
+	cv.infoBox = comp.CreateInfoBox(left+2, bottom-3, right-2, bottom-1, style)
+
+	cv.valueInput.Focus()
+	return cv
+}
+
+// SetCell starts editing column of the table, value is nil for a NULL cell.
+func (cv *CellEditView) SetCell(table, column string, value *string) {
+	cv.confirming = false
+	cv.window.SetTitle([]rune("Edit " + table + "." + column))
+	cv.valueInput.Reset()
+	cv.valueInput.Focus()
+
+	cv.null = value == nil
+	if value != nil {
+		cv.valueInput.SetString(*value)
+	}
+	cv.setLabel()
+}
+
+func (cv *CellEditView) setLabel() {
+	if cv.null {
+		cv.valueInput.SetLabel([]rune("Value: NULL"))
+	} else {
+		cv.valueInput.SetLabel([]rune("Value:"))
+	}
+}
+
+// Confirming reports whether the UPDATE is being shown.
+func (cv *CellEditView) Confirming() bool {
+	return cv.confirming
+}
+
+func (cv *CellEditView) Render(screen tcell.Screen) {
+	cv.window.Render(screen)
+	if cv.confirming {
+		cv.sqlView.Render(screen)
+	} else {
+		cv.valueInput.Render(screen)
+	}
+
+	cv.infoBox.Render(screen)
+}
+
+func (cv *CellEditView) HandleInput(key *tcell.EventKey) {
+	if cv.confirming {
+		switch key.Key() {
+		case tcell.KeyEnter:
+			err := cv.confirmFunc()
+			if err != nil {
+				cv.infoBox.SetMessage("Error: " + err.Error())
+			}
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			cv.confirming = false
+			cv.infoBox.Reset()
+		default:
+			cv.sqlView.HandleInput(key)
+		}
+		return
+	}
+
+	switch key.Key() {
+	case tcell.KeyCtrlN:
+		cv.null = !cv.null
+		cv.setLabel()
+	case tcell.KeyEnter:
+		var value *string
+		if !cv.null {
+			text := cv.valueInput.GetString()
+			value = &text
+		}
+
+		sql, err := cv.prepareFunc(value)
+		if err != nil {
+			cv.infoBox.SetMessage("Error: " + err.Error())
+			break
+		}
+
+		cv.sqlView.SetText("Run this statement?", sql)
+		cv.infoBox.SetMessage("Enter - Run | Backspace - Back to Value | Esc - Cancel")
+		cv.confirming = true
+	default:
+		if cv.null {
+			cv.null = false
+			cv.setLabel()
+		}
+		cv.valueInput.HandleInput(key)
+	}
+}
+
+func (cv *CellEditView) Reset() {
+	cv.infoBox.Reset()
+}
@@ -0,0 +1,53 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+)
+
+// ConfirmView shows a statement before it's run, Enter runs it.
+type ConfirmView struct {
+	window      *comp.Window
+	sqlView     *comp.TextView
+	infoBox     *comp.InfoBox
+	confirmFunc ConfirmFunc
+}
+
+func CreateConfirmView(left, top, right, bottom int, style *tcell.Style, confirmFunc ConfirmFunc) *ConfirmView {
+	cv := &ConfirmView{
+		confirmFunc: confirmFunc,
+		sqlView:     comp.CreateTextView(left, top, right, bottom-3, style),
+		infoBox:     comp.CreateInfoBox(left+2, bottom-3, right-2, bottom-1, style),
+	}
+
+	cv.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune("Confirm"), style)
+	return cv
+}
+
+// SetStatement shows sql under title.
+func (cv *ConfirmView) SetStatement(title, sql string) {
+	cv.sqlView.SetText(title, sql)
+	cv.infoBox.SetMessage("Enter - Run | Esc - Cancel")
+}
+
+func (cv *ConfirmView) Render(screen tcell.Screen) {
+	cv.window.Render(screen)
+	cv.sqlView.Render(screen)
+	cv.infoBox.Render(screen)
+}
+
+func (cv *ConfirmView) HandleInput(key *tcell.EventKey) {
+	if key.Key() != tcell.KeyEnter {
+		cv.sqlView.HandleInput(key)
+		return
+	}
+
+	err := cv.confirmFunc()
+	if err != nil {
+		cv.infoBox.SetMessage("Error: " + err.Error())
+	}
+}
+
+func (cv *ConfirmView) Reset() {
+	cv.infoBox.Reset()
+}
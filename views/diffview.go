@@ -0,0 +1,121 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/db"
+)
+
+var (
+	AddedStyle   tcell.Style = tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorGreen)
+	RemovedStyle tcell.Style = tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorRed)
+	ChangedStyle tcell.Style = tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorYellow)
+)
+
+// DiffView is a popup showing the differences between the schemas of two
+// connections as a tree, S swaps the tree for the migration SQL.
+type DiffView struct {
+	tree    *comp.Tree
+	sqlView *comp.TextView
+	showSQL bool
+	title   string
+	sql     string
+}
+
+func CreateDiffView(left, top, right, bottom int, style *tcell.Style) *DiffView {
+	return &DiffView{
+		tree:    comp.CreateTree(left, top, right, bottom, nil, []rune("Schema Diff"), style),
+		sqlView: comp.CreateTextView(left, top, right, bottom, style),
+	}
+}
+
+// SetDiff shows the changes needed to make the schema of from match to,
+// every changed table is expanded.
+func (dv *DiffView) SetDiff(diff db.SchemaDiff, from, to, sql string) {
+	dv.title = fmt.Sprintf("Migrate %s to match %s", from, to)
+	dv.sql = sql
+	dv.showSQL = false
+
+	var items []*comp.TreeItem
+	for _, v := range diff.Tables {
+		table := &comp.TreeItem{
+			Label: []rune(v.Summary()),
+			Value: v.Name,
+			Style: diffStyle(v.Kind),
+		}
+
+		for _, col := range v.Columns {
+			table.Children = append(table.Children, diffItem(col.Summary(), col.Kind))
+		}
+		for _, index := range v.Indexes {
+			table.Children = append(table.Children, diffItem(index.Summary(), index.Kind))
+		}
+		for _, fk := range v.ForeignKeys {
+			table.Children = append(table.Children, diffItem(fk.Summary(), fk.Kind))
+		}
+
+		table.SetExpanded(v.Kind == db.Changed)
+		items = append(items, table)
+	}
+
+	if diff.Empty() {
+		items = append(items, &comp.TreeItem{Label: []rune(fmt.Sprintf("No differences, %s matches %s", from, to))})
+	}
+
+	dv.tree.SetLabel([]rune(fmt.Sprintf("Schema Diff: %s → %s", from, to)))
+	dv.tree.SetItems(items)
+	dv.tree.Reset()
+}
+
+func (dv *DiffView) ShowingSQL() bool {
+	return dv.showSQL
+}
+
+func (dv *DiffView) HandleInput(ev *tcell.EventKey) {
+	switch {
+	case dv.showSQL && ev.Key() == tcell.KeyEsc:
+		dv.showSQL = false
+	case dv.showSQL:
+		dv.sqlView.HandleInput(ev)
+	case ev.Rune() == 'S':
+		sql := dv.sql
+		if sql == "" {
+			sql = "-- Nothing to migrate"
+		}
+		dv.sqlView.SetText(dv.title, sql)
+		dv.showSQL = true
+	default:
+		dv.tree.HandleInput(ev)
+	}
+}
+
+func (dv *DiffView) Render(screen tcell.Screen) {
+	if dv.showSQL {
+		dv.sqlView.Render(screen)
+		return
+	}
+
+	dv.tree.Render(screen)
+}
+
+func diffItem(label string, kind db.ChangeKind) *comp.TreeItem {
+	return &comp.TreeItem{
+		Label: []rune(label),
+		Level: 1,
+		Child: true,
+		Style: diffStyle(kind),
+	}
+}
+
+func diffStyle(kind db.ChangeKind) *tcell.Style {
+	switch kind {
+	case db.Added:
+		return &AddedStyle
+	case db.Removed:
+		return &RemovedStyle
+	}
+
+	return &ChangedStyle
+}
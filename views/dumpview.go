@@ -0,0 +1,94 @@
+package views
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+)
+
+const (
+	dumpPathInput DVSelected = iota
+	dumpButton
+)
+
+// DumpFunc starts writing a SQL dump of the connected database to path.
+type DumpFunc func(path string) error
+type DVSelected byte
+
+type DumpView struct {
+	selected  DVSelected
+	window    *comp.Window
+	pathInput *comp.TextBox
+	dumpBtn   *comp.Button
+	infoBox   *comp.InfoBox
+	dumpFunc  DumpFunc
+}
+
+func CreateDumpView(left, top, right, bottom int, style *tcell.Style, dumpFunc DumpFunc) *DumpView {
+	dv := &DumpView{
+		selected: dumpPathInput,
+		dumpFunc: dumpFunc,
+	}
+
+	dv.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune("Dump Database to SQL"), style)
+
+	inpLeft, inpTop, inpRight, _ := dv.window.RequestRows(4)
+	dv.pathInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("File Path:"), style)
+
+	inpLeft, inpTop, _, _ = dv.window.RequestRows(3)
+	dv.dumpBtn = comp.CreateButton(inpLeft, inpTop, []rune("Dump"), style)
+
+	inpLeft, inpTop, inpRight, inpBottom := dv.window.RequestRows(3)
+	dv.infoBox = comp.CreateInfoBox(inpLeft, inpTop, inpRight, inpBottom, style)
+
+	dv.pathInput.Focus()
+	return dv
+}
+
+func (dv *DumpView) Render(screen tcell.Screen) {
+	dv.window.Render(screen)
+	dv.pathInput.Render(screen)
+	dv.dumpBtn.Render(screen)
+	dv.infoBox.Render(screen)
+}
+
+func (dv *DumpView) HandleInput(key *tcell.EventKey) {
+	if key.Key() == tcell.KeyTab {
+		dv.pathInput.LoseFocus()
+		dv.dumpBtn.LoseFocus()
+
+		if dv.selected == dumpPathInput {
+			dv.selected = dumpButton
+			dv.dumpBtn.Focus()
+		} else {
+			dv.selected = dumpPathInput
+			dv.pathInput.Focus()
+		}
+		return
+	}
+
+	switch {
+	case dv.selected == dumpPathInput:
+		dv.pathInput.HandleInput(key)
+	case dv.selected == dumpButton && key.Key() == tcell.KeyEnter:
+		path := strings.TrimSpace(dv.pathInput.GetString())
+		if path == "" {
+			dv.infoBox.SetMessage("File path is empty")
+			break
+		}
+
+		err := dv.dumpFunc(path)
+		if err != nil {
+			dv.infoBox.SetMessage("Error: " + err.Error())
+		}
+	}
+}
+
+// Reset clears the message and keeps the path for the next dump.
+func (dv *DumpView) Reset() {
+	dv.infoBox.Reset()
+	dv.dumpBtn.LoseFocus()
+	dv.selected = dumpPathInput
+	dv.pathInput.Focus()
+}
@@ -0,0 +1,158 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/db"
+)
+
+const (
+	formatRadio EVSelected = iota
+	pathInput
+	tableInput
+	exportButton
+)
+
+// ExportFunc writes the current result to path in format, table is the
+// target table for INSERT statements.
+type ExportFunc func(format db.ExportFormat, path, table string) error
+type EVSelected byte
+
+type ExportView struct {
+	selected    EVSelected
+	window      *comp.Window
+	formatRadio *comp.RadioSelect
+	pathInput   *comp.TextBox
+	tableInput  *comp.TextBox
+	exportBtn   *comp.Button
+	infoBox     *comp.InfoBox
+	exportFunc  ExportFunc
+}
+
+func CreateExportView(left, top, right, bottom int, style, hlStyle *tcell.Style, exportFunc ExportFunc) *ExportView {
+	ev := &ExportView{
+		selected:   formatRadio,
+		exportFunc: exportFunc,
+	}
+
+	ev.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune("Export Results"), style)
+
+	inpLeft, inpTop, inpRight, inpBottom := ev.window.RequestRows(4)
+	ev.formatRadio = comp.CreateRadioSelect(inpLeft, inpTop, inpRight, inpBottom, []rune("Format:"), exportFormats(), style, hlStyle)
+
+	inpLeft, inpTop, inpRight, _ = ev.window.RequestRows(4)
+	ev.pathInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("File Path:"), style)
+
+	inpLeft, inpTop, inpRight, _ = ev.window.RequestRows(4)
+	ev.tableInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("Target Table (INSERT only):"), style)
+
+	inpLeft, inpTop, _, _ = ev.window.RequestRows(3)
+	ev.exportBtn = comp.CreateButton(inpLeft, inpTop, []rune("Export"), style)
+
+	inpLeft, inpTop, inpRight, inpBottom = ev.window.RequestRows(3)
+	ev.infoBox = comp.CreateInfoBox(inpLeft, inpTop, inpRight, inpBottom, style)
+
+	ev.formatRadio.Focus()
+	return ev
+}
+
+func exportFormats() []comp.ListItem[string] {
+	var items []comp.ListItem[string]
+	for _, v := range db.ExportFormats() {
+		label := v.String()
+		if note := v.Note(); note != "" {
+			label += " (" + note + ")"
+		}
+
+		items = append(items, comp.ListItem[string]{
+			Label: []rune(label),
+			Value: v.String(),
+		})
+	}
+
+	return items
+}
+
+func (ev *ExportView) ResetFocus() {
+	ev.formatRadio.LoseFocus()
+	ev.pathInput.LoseFocus()
+	ev.tableInput.LoseFocus()
+	ev.exportBtn.LoseFocus()
+}
+
+func (ev *ExportView) Render(screen tcell.Screen) {
+	ev.window.Render(screen)
+	ev.formatRadio.Render(screen)
+	ev.pathInput.Render(screen)
+	ev.tableInput.Render(screen)
+	ev.exportBtn.Render(screen)
+	ev.infoBox.Render(screen)
+}
+
+func (ev *ExportView) HandleInput(key *tcell.EventKey) {
+	if key.Key() == tcell.KeyTab {
+		ev.ResetFocus()
+
+		switch ev.selected {
+		case formatRadio:
+			ev.selected = pathInput
+			ev.pathInput.Focus()
+		case pathInput:
+			ev.selected = tableInput
+			ev.tableInput.Focus()
+		case tableInput:
+			ev.selected = exportButton
+			ev.exportBtn.Focus()
+		case exportButton:
+			ev.selected = formatRadio
+			ev.formatRadio.Focus()
+		}
+		return
+	}
+
+	switch {
+	case ev.selected == formatRadio:
+		ev.formatRadio.HandleInput(key)
+	case ev.selected == pathInput:
+		ev.pathInput.HandleInput(key)
+	case ev.selected == tableInput:
+		ev.tableInput.HandleInput(key)
+	case ev.selected == exportButton && key.Key() == tcell.KeyEnter:
+		format, ok := ev.format()
+		if !ok {
+			ev.infoBox.SetMessage("No format selected")
+			break
+		}
+
+		path := ev.pathInput.GetString()
+		if path == "" {
+			ev.infoBox.SetMessage("File path is empty")
+			break
+		}
+
+		err := ev.exportFunc(format, path, ev.tableInput.GetString())
+		if err != nil {
+			ev.infoBox.SetMessage("Error: " + err.Error())
+		}
+	}
+}
+
+func (ev *ExportView) format() (db.ExportFormat, bool) {
+	selection := ev.formatRadio.GetSelection()
+	for _, v := range db.ExportFormats() {
+		if v.String() == selection {
+			return v, true
+		}
+	}
+
+	return 0, false
+}
+
+// Reset clears the message and moves focus back to the format, the inputs
+// are kept so the same export can be repeated.
+func (ev *ExportView) Reset() {
+	ev.ResetFocus()
+	ev.infoBox.Reset()
+	ev.selected = formatRadio
+	ev.formatRadio.Focus()
+}
@@ -0,0 +1,129 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+)
+
+type formField struct {
+	name  string
+	label string
+	input *comp.TextBox
+	null  bool
+}
+
+// fieldForm is a window of labelled text fields that can each be set to
+// NULL with Ctrl-N, the fields are paged when there are more than fit.
+type fieldForm struct {
+	title    string
+	selected int
+	fields   []formField
+	slots    [][3]int
+	style    *tcell.Style
+	window   *comp.Window
+}
+
+// createFieldForm fills the window with field slots, leaving reserve rows at
+// the bottom for the view to request.
+func createFieldForm(left, top, right, bottom, reserve int, title string, style *tcell.Style) *fieldForm {
+	form := &fieldForm{
+		title: title,
+		style: style,
+	}
+
+	form.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune(title), style)
+
+	perPage := max((bottom-top-4-reserve)/4, 1)
+	for range perPage {
+		inpLeft, inpTop, inpRight, _ := form.window.RequestRows(4)
+		form.slots = append(form.slots, [3]int{inpLeft, inpTop, inpRight})
+	}
+
+	return form
+}
+
+// clear removes the fields ready for a new set.
+func (form *fieldForm) clear() {
+	form.fields = nil
+	form.selected = 0
+}
+
+// addField adds a field for name shown with label.
+func (form *fieldForm) addField(name, label string) *formField {
+	slot := form.slots[len(form.fields)%len(form.slots)]
+	form.fields = append(form.fields, formField{
+		name:  name,
+		label: label,
+		input: comp.CreateTextBox(slot[0], slot[1], slot[2], nil, form.style),
+	})
+
+	form.setLabel(len(form.fields) - 1)
+	return &form.fields[len(form.fields)-1]
+}
+
+func (form *fieldForm) setLabel(i int) {
+	label := form.fields[i].label + ":"
+	if form.fields[i].null {
+		label += " NULL"
+	}
+
+	form.fields[i].input.SetLabel([]rune(label))
+}
+
+func (form *fieldForm) focus() {
+	for _, v := range form.fields {
+		v.input.LoseFocus()
+	}
+
+	if len(form.fields) == 0 {
+		return
+	}
+
+	form.fields[form.selected].input.Focus()
+
+	title := form.title
+	if pages := (len(form.fields) + len(form.slots) - 1) / len(form.slots); pages > 1 {
+		title = fmt.Sprintf("%s (page %d of %d)", form.title, form.selected/len(form.slots)+1, pages)
+	}
+	form.window.SetTitle([]rune(title))
+}
+
+func (form *fieldForm) render(screen tcell.Screen) {
+	form.window.Render(screen)
+
+	page := form.selected / len(form.slots)
+	for i, v := range form.fields {
+		if i/len(form.slots) == page {
+			v.input.Render(screen)
+		}
+	}
+}
+
+// handleInput moves between the fields and edits them, Enter is left to the
+// view.
+func (form *fieldForm) handleInput(key *tcell.EventKey) {
+	if len(form.fields) == 0 {
+		return
+	}
+
+	field := &form.fields[form.selected]
+	switch key.Key() {
+	case tcell.KeyTab, tcell.KeyDown:
+		form.selected = (form.selected + 1) % len(form.fields)
+		form.focus()
+	case tcell.KeyBacktab, tcell.KeyUp:
+		form.selected = (form.selected + len(form.fields) - 1) % len(form.fields)
+		form.focus()
+	case tcell.KeyCtrlN:
+		field.null = !field.null
+		form.setLabel(form.selected)
+	default:
+		if field.null {
+			field.null = false
+			form.setLabel(form.selected)
+		}
+		field.input.HandleInput(key)
+	}
+}
@@ -0,0 +1,67 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+)
+
+// FilterFunc applies a WHERE condition, an empty one clears the filter.
+type FilterFunc func(filter string) error
+
+// FilterView is a one line prompt for the WHERE condition of the table
+// being browsed.
+type FilterView struct {
+	window     *comp.Window
+	input      *comp.TextBox
+	infoBox    *comp.InfoBox
+	filterFunc FilterFunc
+}
+
+func CreateFilterView(left, top, right, bottom int, style *tcell.Style, filterFunc FilterFunc) *FilterView {
+	fv := &FilterView{
+		filterFunc: filterFunc,
+	}
+
+	fv.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune("Filter"), style)
+
+	inpLeft, inpTop, inpRight, _ := fv.window.RequestRows(4)
+	fv.input = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("WHERE"), style)
+	fv.input.SetPlaceholder([]rune("leave empty to show every row"))
+
+	inpLeft, inpTop, inpRight, inpBottom := fv.window.RequestRows(3)
+	fv.infoBox = comp.CreateInfoBox(inpLeft, inpTop, inpRight, inpBottom, style)
+
+	fv.input.Focus()
+	return fv
+}
+
+// SetFilter starts the prompt for table with its current filter.
+func (fv *FilterView) SetFilter(table, filter string) {
+	fv.window.SetTitle([]rune("Filter " + table))
+	fv.input.Reset()
+	fv.input.SetString(filter)
+	fv.input.Focus()
+	fv.infoBox.Reset()
+}
+
+func (fv *FilterView) Render(screen tcell.Screen) {
+	fv.window.Render(screen)
+	fv.input.Render(screen)
+	fv.infoBox.Render(screen)
+}
+
+func (fv *FilterView) HandleInput(key *tcell.EventKey) {
+	if key.Key() != tcell.KeyEnter {
+		fv.input.HandleInput(key)
+		return
+	}
+
+	err := fv.filterFunc(fv.input.GetString())
+	if err != nil {
+		fv.infoBox.SetMessage("Error: " + err.Error())
+	}
+}
+
+func (fv *FilterView) Reset() {
+	fv.infoBox.Reset()
+}
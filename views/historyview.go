@@ -0,0 +1,136 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/util"
+)
+
+// PasteFunc puts a statement from the history or a snippet into the editor.
+type PasteFunc func(statement string)
+
+// HistoryView lists the statements that have been run, typing filters them
+// with a fuzzy search.
+type HistoryView struct {
+	width       int
+	entries     []util.HistoryEntry
+	window      *comp.Window
+	searchInput *comp.TextBox
+	historyList *comp.List[util.HistoryEntry]
+	infoBox     *comp.InfoBox
+	pasteFunc   PasteFunc
+}
+
+func CreateHistoryView(left, top, right, bottom int, style *tcell.Style, pasteFunc PasteFunc) *HistoryView {
+	hv := &HistoryView{
+		width:     right - left - 4,
+		pasteFunc: pasteFunc,
+	}
+
+	hv.window = comp.CreateWindow(left, top, right, bottom, 1, 1, true, true, []rune("Query History"), style)
+
+	inpLeft, inpTop, inpRight, _ := hv.window.RequestRows(4)
+	hv.searchInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("Search:"), style)
+
+	hv.historyList = comp.CreateList[util.HistoryEntry](left+2, inpTop+4, right-2, bottom-3, nil, nil, style)
+	hv.infoBox = comp.CreateInfoBox(left+2, bottom-3, right-2, bottom-1, style)
+
+	hv.searchInput.Focus()
+	return hv
+}
+
+// SetEntries replaces the history shown, entries are oldest first.
+func (hv *HistoryView) SetEntries(entries []util.HistoryEntry) {
+	hv.entries = entries
+	hv.search()
+}
+
+func (hv *HistoryView) search() {
+	var items []comp.ListItem[util.HistoryEntry]
+	for _, v := range util.SearchHistory(hv.entries, hv.searchInput.GetString()) {
+		items = append(items, comp.ListItem[util.HistoryEntry]{
+			Label: hv.label(v),
+			Value: v,
+		})
+	}
+
+	hv.historyList.SetList(items)
+	hv.historyList.SetTitle([]rune(fmt.Sprintf("%d of %d statements", len(items), len(hv.entries))))
+	hv.showSelected()
+}
+
+func (hv *HistoryView) label(entry util.HistoryEntry) []rune {
+	status := "ok"
+	if entry.Error != "" {
+		status = "error"
+	}
+
+	rows := "-"
+	if entry.Rows >= 0 {
+		rows = fmt.Sprintf("%d rows", entry.Rows)
+	}
+
+	conn := []rune(entry.Connection)
+	if len(conn) > 12 {
+		conn = append(conn[:11], '…')
+	}
+
+	label := []rune(fmt.Sprintf("%s %-12s %-5s %8s %10s  %s",
+		entry.RanAt.Local().Format("01-02 15:04"),
+		string(conn),
+		status,
+		entry.Duration.Round(time.Millisecond),
+		rows,
+		strings.Join(strings.Fields(entry.Statement), " "),
+	))
+	if len(label) > hv.width {
+		label = append(label[:hv.width-1], '…')
+	}
+
+	return label
+}
+
+// showSelected shows the error for the selected statement if it failed.
+func (hv *HistoryView) showSelected() {
+	item := hv.historyList.SelectedItem()
+	if item == nil || item.Value.Error == "" {
+		hv.infoBox.Reset()
+		return
+	}
+
+	hv.infoBox.SetMessage("Error: " + item.Value.Error)
+}
+
+func (hv *HistoryView) Render(screen tcell.Screen) {
+	hv.window.Render(screen)
+	hv.searchInput.Render(screen)
+	hv.historyList.Render(screen)
+	hv.infoBox.Render(screen)
+}
+
+func (hv *HistoryView) HandleInput(key *tcell.EventKey) {
+	switch key.Key() {
+	case tcell.KeyUp, tcell.KeyDown:
+		hv.historyList.HandleInput(key)
+		hv.showSelected()
+	case tcell.KeyEnter:
+		item := hv.historyList.SelectedItem()
+		if item != nil {
+			hv.pasteFunc(item.Value.Statement)
+		}
+	default:
+		hv.searchInput.HandleInput(key)
+		hv.search()
+	}
+}
+
+// Reset clears the search so the newest statements are shown first.
+func (hv *HistoryView) Reset() {
+	hv.searchInput.Reset()
+	hv.searchInput.Focus()
+	hv.search()
+}
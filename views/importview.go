@@ -0,0 +1,366 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/db"
+)
+
+const (
+	importFormatRadio IVSelected = iota
+	importPathInput
+	importModeRadio
+	importTableInput
+	importButton
+)
+
+const (
+	ImportForm ImportStage = iota
+	ImportTables
+	ImportMapping
+)
+
+const (
+	newTableMode = "new"
+	appendMode   = "append"
+)
+
+// ImportLoadFunc reads the file at path, ImportFunc starts importing its
+// rows with plan.
+type ImportLoadFunc func(path string, format db.ExportFormat) (*db.ImportData, error)
+type ImportFunc func(data *db.ImportData, plan db.ImportPlan) error
+type IVSelected byte
+type ImportStage byte
+
+// ImportView is the form for importing a delimited file. Appending to an
+// existing table goes on to a list of the tables and then the column
+// mapping, where each of the table's columns is given a field of the file
+// or skipped.
+type ImportView struct {
+	selected    IVSelected
+	stage       ImportStage
+	driver      string
+	tables      []db.Table
+	data        *db.ImportData
+	target      db.Table
+	fields      []int
+	mapWidth    int
+	window      *comp.Window
+	formatRadio *comp.RadioSelect
+	pathInput   *comp.TextBox
+	modeRadio   *comp.RadioSelect
+	tableInput  *comp.TextBox
+	importBtn   *comp.Button
+	infoBox     *comp.InfoBox
+	tableList   *comp.List[db.Table]
+	mapWindow   *comp.Window
+	mappingList *comp.List[int]
+	mappingInfo *comp.InfoBox
+	loadFunc    ImportLoadFunc
+	importFunc  ImportFunc
+}
+
+func CreateImportView(left, top, right, bottom int, style, hlStyle *tcell.Style, loadFunc ImportLoadFunc, importFunc ImportFunc) *ImportView {
+	iv := &ImportView{
+		selected:   importFormatRadio,
+		loadFunc:   loadFunc,
+		importFunc: importFunc,
+	}
+
+	iv.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune("Import CSV/TSV"), style)
+
+	inpLeft, inpTop, inpRight, inpBottom := iv.window.RequestRows(4)
+	iv.formatRadio = comp.CreateRadioSelect(inpLeft, inpTop, inpRight, inpBottom, []rune("Format (from the extension if unset):"), importFormats(), style, hlStyle)
+
+	inpLeft, inpTop, inpRight, _ = iv.window.RequestRows(4)
+	iv.pathInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("File Path (first row is the header):"), style)
+
+	modes := []comp.ListItem[string]{
+		{Label: []rune("New Table"), Value: newTableMode},
+		{Label: []rune("Append"), Value: appendMode},
+	}
+	inpLeft, inpTop, inpRight, inpBottom = iv.window.RequestRows(4)
+	iv.modeRadio = comp.CreateRadioSelect(inpLeft, inpTop, inpRight, inpBottom, []rune("Import Into:"), modes, style, hlStyle)
+
+	inpLeft, inpTop, inpRight, _ = iv.window.RequestRows(4)
+	iv.tableInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("New Table Name:"), style)
+
+	inpLeft, inpTop, _, _ = iv.window.RequestRows(3)
+	iv.importBtn = comp.CreateButton(inpLeft, inpTop, []rune("Import"), style)
+
+	inpLeft, inpTop, inpRight, inpBottom = iv.window.RequestRows(3)
+	iv.infoBox = comp.CreateInfoBox(inpLeft, inpTop, inpRight, inpBottom, style)
+
+	iv.tableList = comp.CreateList[db.Table](left, top, right, bottom, nil, []rune("Append to which table?"), style)
+
+	iv.mapWidth = right - left - 6
+	iv.mapWindow = comp.CreateWindow(left, top, right, bottom, 1, 1, true, true, []rune("Map Columns"), style)
+	iv.mappingList = comp.CreateList[int](left+2, top+2, right-2, bottom-3, nil, []rune("Table column <- File field"), style)
+	iv.mappingInfo = comp.CreateInfoBox(left+2, bottom-3, right-2, bottom-1, style)
+
+	iv.formatRadio.Focus()
+	return iv
+}
+
+func importFormats() []comp.ListItem[string] {
+	var items []comp.ListItem[string]
+	for _, v := range db.ImportFormats() {
+		items = append(items, comp.ListItem[string]{
+			Label: []rune(v.String()),
+			Value: v.String(),
+		})
+	}
+
+	return items
+}
+
+// SetTables sets the tables that can be appended to and the driver new
+// tables' column types are picked for.
+func (iv *ImportView) SetTables(tables []db.Table, driver string) {
+	iv.tables = tables
+	iv.driver = driver
+
+	var items []comp.ListItem[db.Table]
+	for _, v := range tables {
+		if v.Type != db.TableObject {
+			continue
+		}
+
+		items = append(items, comp.ListItem[db.Table]{
+			Label: []rune(v.Name),
+			Value: v,
+		})
+	}
+
+	iv.tableList.SetList(items)
+}
+
+func (iv *ImportView) Stage() ImportStage {
+	return iv.stage
+}
+
+func (iv *ImportView) ResetFocus() {
+	iv.formatRadio.LoseFocus()
+	iv.pathInput.LoseFocus()
+	iv.modeRadio.LoseFocus()
+	iv.tableInput.LoseFocus()
+	iv.importBtn.LoseFocus()
+}
+
+func (iv *ImportView) Render(screen tcell.Screen) {
+	switch iv.stage {
+	case ImportTables:
+		iv.tableList.Render(screen)
+	case ImportMapping:
+		iv.mapWindow.Render(screen)
+		iv.mappingList.Render(screen)
+		iv.mappingInfo.Render(screen)
+	default:
+		iv.window.Render(screen)
+		iv.formatRadio.Render(screen)
+		iv.pathInput.Render(screen)
+		iv.modeRadio.Render(screen)
+		iv.tableInput.Render(screen)
+		iv.importBtn.Render(screen)
+		iv.infoBox.Render(screen)
+	}
+}
+
+func (iv *ImportView) HandleInput(key *tcell.EventKey) {
+	switch iv.stage {
+	case ImportTables:
+		iv.handleTablesInput(key)
+		return
+	case ImportMapping:
+		iv.handleMappingInput(key)
+		return
+	}
+
+	if key.Key() == tcell.KeyTab {
+		iv.ResetFocus()
+
+		switch iv.selected {
+		case importFormatRadio:
+			iv.selected = importPathInput
+			iv.pathInput.Focus()
+		case importPathInput:
+			iv.selected = importModeRadio
+			iv.modeRadio.Focus()
+		case importModeRadio:
+			iv.selected = importTableInput
+			iv.tableInput.Focus()
+		case importTableInput:
+			iv.selected = importButton
+			iv.importBtn.Focus()
+		default:
+			iv.selected = importFormatRadio
+			iv.formatRadio.Focus()
+		}
+		return
+	}
+
+	switch {
+	case iv.selected == importFormatRadio:
+		iv.formatRadio.HandleInput(key)
+	case iv.selected == importPathInput:
+		iv.pathInput.HandleInput(key)
+	case iv.selected == importModeRadio:
+		iv.modeRadio.HandleInput(key)
+	case iv.selected == importTableInput:
+		iv.tableInput.HandleInput(key)
+	case iv.selected == importButton && key.Key() == tcell.KeyEnter:
+		iv.submitForm()
+	}
+}
+
+// submitForm reads the file and either starts importing it into a new
+// table or moves on to picking the table to append to.
+func (iv *ImportView) submitForm() {
+	path := strings.TrimSpace(iv.pathInput.GetString())
+	if path == "" {
+		iv.infoBox.SetMessage("File path is empty")
+		return
+	}
+
+	format := db.FormatForPath(path)
+	for _, v := range db.ImportFormats() {
+		if v.String() == iv.formatRadio.GetSelection() {
+			format = v
+		}
+	}
+
+	appending := iv.modeRadio.GetSelection() == appendMode
+	if appending && iv.tableList.SelectedItem() == nil {
+		iv.infoBox.SetMessage("There are no tables to append to")
+		return
+	}
+
+	name := strings.TrimSpace(iv.tableInput.GetString())
+	if !appending {
+		for _, v := range iv.tables {
+			if strings.EqualFold(v.Name, name) {
+				iv.infoBox.SetMessage(fmt.Sprintf("%s already exists, pick Append to add rows to it", v.Name))
+				return
+			}
+		}
+	}
+
+	data, err := iv.loadFunc(path, format)
+	if err != nil {
+		iv.infoBox.SetMessage("Error: " + err.Error())
+		return
+	}
+
+	if appending {
+		iv.data = data
+		iv.stage = ImportTables
+		return
+	}
+
+	plan, err := db.NewTablePlan(data, name, iv.driver)
+	if err != nil {
+		iv.infoBox.SetMessage("Error: " + err.Error())
+		return
+	}
+
+	iv.start(data, plan, iv.infoBox)
+}
+
+func (iv *ImportView) handleTablesInput(key *tcell.EventKey) {
+	if key.Key() != tcell.KeyEnter {
+		iv.tableList.HandleInput(key)
+		return
+	}
+
+	item := iv.tableList.SelectedItem()
+	if item == nil {
+		return
+	}
+
+	iv.target = item.Value
+	iv.stage = ImportMapping
+	iv.mapWindow.SetTitle([]rune(fmt.Sprintf("Map Columns into %s", iv.target.Name)))
+	iv.fields = db.AutoMapping(iv.data, iv.target)
+
+	var items []comp.ListItem[int]
+	for i := range iv.target.Columns {
+		items = append(items, comp.ListItem[int]{
+			Label: iv.mappingLabel(i),
+			Value: i,
+		})
+	}
+
+	iv.mappingList.SetList(items)
+	iv.mappingInfo.SetMessage(fmt.Sprintf("%d fields in the file, columns without one are left out", len(iv.data.Columns)))
+}
+
+// mappingLabel shows column i of the target table and the field going into
+// it.
+func (iv *ImportView) mappingLabel(i int) []rune {
+	field := "(skipped)"
+	if index := iv.fields[i]; index >= 0 {
+		field = iv.data.Columns[index]
+	}
+
+	label := []rune(fmt.Sprintf("%-20s <- %s", iv.target.Columns[i].Name, field))
+	if len(label) > iv.mapWidth {
+		label = append(label[:iv.mapWidth-1], '…')
+	}
+
+	return label
+}
+
+// handleMappingInput moves between the table's columns with Up and Down,
+// Left and Right step through the file's fields for the selected column and
+// Enter starts the import.
+func (iv *ImportView) handleMappingInput(key *tcell.EventKey) {
+	item := iv.mappingList.SelectedItem()
+
+	switch key.Key() {
+	case tcell.KeyLeft, tcell.KeyRight:
+		if item == nil {
+			return
+		}
+
+		// -1 skips the column so there's one more choice than there are
+		// fields.
+		step := 1
+		if key.Key() == tcell.KeyLeft {
+			step = len(iv.data.Columns)
+		}
+		iv.fields[item.Value] = (iv.fields[item.Value]+1+step)%(len(iv.data.Columns)+1) - 1
+		item.Label = iv.mappingLabel(item.Value)
+	case tcell.KeyEnter:
+		plan, err := db.AppendPlan(iv.data, iv.target, iv.fields)
+		if err != nil {
+			iv.mappingInfo.SetMessage("Error: " + err.Error())
+			return
+		}
+
+		iv.start(iv.data, plan, iv.mappingInfo)
+	default:
+		iv.mappingList.HandleInput(key)
+	}
+}
+
+func (iv *ImportView) start(data *db.ImportData, plan db.ImportPlan, infoBox *comp.InfoBox) {
+	err := iv.importFunc(data, plan)
+	if err != nil {
+		infoBox.SetMessage("Error: " + err.Error())
+	}
+}
+
+// Reset goes back to the first form and drops the file that was read, the
+// inputs are kept so a failed import can be retried.
+func (iv *ImportView) Reset() {
+	iv.ResetFocus()
+	iv.infoBox.Reset()
+	iv.mappingInfo.Reset()
+	iv.stage = ImportForm
+	iv.data = nil
+	iv.selected = importFormatRadio
+	iv.formatRadio.Focus()
+}
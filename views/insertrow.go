@@ -0,0 +1,99 @@
+package views
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/db"
+)
+
+// InsertFunc inserts a row from values keyed by column name, a nil value is
+// NULL and columns left empty aren't included.
+type InsertFunc func(values map[string]*string) error
+
+// InsertRowView is a form with a field for each column of a table, a
+// column's default is shown while its field is empty.
+type InsertRowView struct {
+	form       *fieldForm
+	infoBox    *comp.InfoBox
+	insertFunc InsertFunc
+}
+
+func CreateInsertRowView(left, top, right, bottom int, style *tcell.Style, insertFunc InsertFunc) *InsertRowView {
+	iv := &InsertRowView{
+		insertFunc: insertFunc,
+		form:       createFieldForm(left, top, right, bottom, 3, "Insert Row", style),
+	}
+
+	inpLeft, inpTop, inpRight, inpBottom := iv.form.window.RequestRows(3)
+	iv.infoBox = comp.CreateInfoBox(inpLeft, inpTop, inpRight, inpBottom, style)
+
+	return iv
+}
+
+// SetTable builds a field for each column of table, labelled with its type
+// and whether it's part of the primary key or NOT NULL.
+func (iv *InsertRowView) SetTable(table db.Table) {
+	iv.form.clear()
+	iv.form.title = "Insert Into " + table.Name
+
+	for _, col := range table.Columns {
+		label := []string{col.Name}
+		if col.Type != "" {
+			label = append(label, col.Type)
+		}
+		if col.PrimaryKey {
+			label = append(label, "PK")
+		}
+		if col.NotNull {
+			label = append(label, "NOT NULL")
+		}
+
+		field := iv.form.addField(col.Name, strings.Join(label, " "))
+		if col.DefaultValue != nil {
+			field.input.SetPlaceholder([]rune("default: " + *col.DefaultValue))
+		}
+	}
+
+	iv.infoBox.SetMessage("Empty fields take the column's default")
+	iv.form.focus()
+}
+
+// Values is the fields that were filled in or set to NULL.
+func (iv *InsertRowView) Values() map[string]*string {
+	values := make(map[string]*string)
+	for _, v := range iv.form.fields {
+		if v.null {
+			values[v.name] = nil
+			continue
+		}
+
+		if value := v.input.GetString(); value != "" {
+			values[v.name] = &value
+		}
+	}
+
+	return values
+}
+
+func (iv *InsertRowView) Render(screen tcell.Screen) {
+	iv.form.render(screen)
+	iv.infoBox.Render(screen)
+}
+
+func (iv *InsertRowView) HandleInput(key *tcell.EventKey) {
+	if key.Key() != tcell.KeyEnter {
+		iv.form.handleInput(key)
+		return
+	}
+
+	err := iv.insertFunc(iv.Values())
+	if err != nil {
+		iv.infoBox.SetMessage("Error: " + err.Error())
+	}
+}
+
+func (iv *InsertRowView) Reset() {
+	iv.infoBox.Reset()
+}
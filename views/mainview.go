@@ -2,6 +2,8 @@ package views
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	comp "github.com/sleepy-day/sqline/components"
@@ -22,6 +24,8 @@ const (
 	DataTable
 	DataTableExpanded
 	Indexes
+	Plan
+	Definition
 
 	OpenConnStatus = "OpenConn"
 	NewConnStatus  = "NewConn"
@@ -39,8 +43,16 @@ var (
 	IndexesStatusStyle      tcell.Style = tcell.StyleDefault.Background(tcell.ColorDarkGreen).Foreground(tcell.ColorWhite)
 	NewConnStatusStyle      tcell.Style = tcell.StyleDefault.Background(tcell.ColorMintCream).Foreground(tcell.ColorBlack)
 	OpenConnStatusStyle     tcell.Style = tcell.StyleDefault.Background(tcell.ColorCoral).Foreground(tcell.ColorWhite)
+	PlanStatusStyle         tcell.Style = tcell.StyleDefault.Background(tcell.ColorSteelBlue).Foreground(tcell.ColorWhite)
+	FullScanStyle           tcell.Style = tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorRed).Bold(true)
 )
 
+// objectDefinition is the SQL shown for an item in the table tree.
+type objectDefinition struct {
+	title string
+	sql   string
+}
+
 type MainView struct {
 	showDB, showSchema        bool
 	left, top, right, bottom  int
@@ -54,6 +66,14 @@ type MainView struct {
 	indexTree                 *comp.Tree
 	tableTree                 *comp.Tree
 	dataTable                 *comp.Table
+	planTree                  *comp.Tree
+	showPlan                  bool
+	definitionView            *comp.TextView
+	definitions               map[*comp.TreeItem]objectDefinition
+	indexDefinitions          map[*comp.TreeItem]objectDefinition
+	tableItems                map[*comp.TreeItem]db.Table
+	definitionReturn          MainViewState
+	definitionSQL             string
 	status                    *comp.StatusBar
 	State                     MainViewState
 }
@@ -100,7 +120,10 @@ func CreateMainView(left, top, right, bottom, pLeft, pTop, pRight, pBottom int,
 
 	tableHeight := 16
 	view.editor = comp.CreateEditor(mainSideStart, view.top, view.right, viewBottom-tableHeight, nil, style, hlStyle)
+	view.SetDriver("")
 	view.dataTable = comp.CreateTable(mainSideStart, view.bottom-tableHeight, view.right, viewBottom, pLeft, pTop, pRight, pBottom, 30, nil, style)
+	view.planTree = comp.CreateTree(mainSideStart, view.bottom-tableHeight, view.right, viewBottom, nil, []rune("Query Plan"), style)
+	view.definitionView = comp.CreateTextView(pLeft, pTop, pRight, pBottom, style)
 	view.status = comp.CreateStatusBar(view.left, view.bottom, view.right, 5, []rune("Normal"), style, &NoModeStatusStyle)
 
 	return view
@@ -113,7 +136,7 @@ func (view *MainView) SetVisibleComponents(showDB, showSchema bool, screen tcell
 	bottom := view.bottom - 1
 	if view.showDB && view.showSchema {
 		view.dbList.Resize(view.left, view.top, view.sideWidth, view.sideListHeight)
-		view.schemaList.Resize(view.left, view.sideListHeight+1, view.sideListHeight, (view.sideListHeight*2)+1)
+		view.schemaList.Resize(view.left, view.sideListHeight+1, view.sideWidth, view.sideListHeight*2)
 		view.tableTree.Resize(view.left, (view.sideListHeight*2)+1, view.sideWidth, bottom-view.sideListHeight)
 		view.indexTree.Resize(view.left, bottom-view.sideListHeight+1, view.sideWidth, bottom)
 	} else if view.showDB {
@@ -168,6 +191,7 @@ func (view *MainView) SetSchemaList(schemaInfo []db.SchemaInfo) {
 }
 
 func (view *MainView) SetIndexTree(tables []db.Table) {
+	view.indexDefinitions = make(map[*comp.TreeItem]objectDefinition)
 	if len(tables) == 0 {
 		view.indexTree.SetItems([]*comp.TreeItem{})
 	}
@@ -187,6 +211,10 @@ func (view *MainView) SetIndexTree(tables []db.Table) {
 				Level: 1,
 				Child: true,
 			}
+			view.indexDefinitions[index] = objectDefinition{
+				title: "Index: " + ix.Name,
+				sql:   ix.Definition,
+			}
 
 			for _, col := range ix.Cols {
 				col := &comp.TreeItem{
@@ -208,34 +236,115 @@ func (view *MainView) SetIndexTree(tables []db.Table) {
 	view.indexTree.SetItems(items)
 }
 
+// SetTableTree shows tables, views and virtual tables in their own groups
+// followed by the triggers on all of them.
 func (view *MainView) SetTableTree(tables []db.Table) {
-	if len(tables) == 0 {
-		view.tableTree.SetItems([]*comp.TreeItem{})
-	}
+	view.definitions = make(map[*comp.TreeItem]objectDefinition)
+	view.tableItems = make(map[*comp.TreeItem]db.Table)
 
 	var items []*comp.TreeItem
-	for _, v := range tables {
-		table := &comp.TreeItem{
-			Label: []rune(v.Name),
-			Value: v.Name,
-			Level: 0,
-		}
+	for _, objType := range []db.ObjectType{db.TableObject, db.ViewObject, db.VirtualTableObject} {
+		group := &comp.TreeItem{Level: 0}
 
-		for _, col := range v.Columns {
-			table.Children = append(table.Children, &comp.TreeItem{
-				Label: []rune(fmt.Sprintf("%s - %s", col.Name, col.Type)),
+		for _, v := range tables {
+			if v.Type != objType {
+				continue
+			}
+
+			table := &comp.TreeItem{
+				Label: []rune(v.Name),
+				Value: v.Name,
+				Level: 1,
 				Child: true,
+			}
+			view.definitions[table] = objectDefinition{
+				title: fmt.Sprintf("%s: %s", v.Type, v.Name),
+				sql:   v.Definition,
+			}
+			if v.Type == db.TableObject {
+				view.tableItems[table] = v
+			}
+
+			for _, col := range v.Columns {
+				label := fmt.Sprintf("%s - %s", col.Name, col.Type)
+				if refTable, refColumn, ok := v.ColumnForeignKey(col.Name); ok {
+					label += fmt.Sprintf(" → %s.%s", refTable, refColumn)
+				}
+
+				column := &comp.TreeItem{
+					Label: []rune(label),
+					Child: true,
+					Level: 2,
+					Value: col.Name,
+				}
+				if v.Type == db.TableObject {
+					view.tableItems[column] = v
+				}
+
+				table.Children = append(table.Children, column)
+			}
+
+			group.Children = append(group.Children, table)
+		}
+
+		if len(group.Children) == 0 {
+			continue
+		}
+
+		group.Label = []rune(fmt.Sprintf("%ss (%d)", objType, len(group.Children)))
+		group.SetExpanded(true)
+		items = append(items, group)
+	}
+
+	triggers := &comp.TreeItem{Level: 0}
+	for _, v := range tables {
+		for _, tr := range v.Triggers {
+			trigger := &comp.TreeItem{
+				Label: []rune(fmt.Sprintf("%s - on %s", tr.Name, tr.TableName)),
+				Value: tr.Name,
 				Level: 1,
-				Value: col.Name,
-			})
+				Child: true,
+			}
+			view.definitions[trigger] = objectDefinition{
+				title: "Trigger: " + tr.Name,
+				sql:   tr.Definition,
+			}
+
+			triggers.Children = append(triggers.Children, trigger)
 		}
+	}
 
-		items = append(items, table)
+	if len(triggers.Children) > 0 {
+		triggers.Label = []rune(fmt.Sprintf("Triggers (%d)", len(triggers.Children)))
+		items = append(items, triggers)
 	}
 
 	view.tableTree.SetItems(items)
 }
 
+// showDefinition opens the SQL for the selected item of tree in a popup,
+// Esc goes back to the tree and e takes the SQL to the editor.
+func (view *MainView) showDefinition(tree *comp.Tree, definitions map[*comp.TreeItem]objectDefinition) {
+	item := tree.SelectedItem()
+	def, ok := definitions[item]
+	switch {
+	case !ok && tree == view.indexTree:
+		view.SetInfo([]rune("Select an index to see its definition"))
+		return
+	case !ok:
+		view.SetInfo([]rune("Select a table, view or trigger to see its definition"))
+		return
+	case def.sql == "":
+		view.SetInfo([]rune(fmt.Sprintf("No SQL definition is stored for %s", def.title)))
+		return
+	}
+
+	view.definitionView.SetText(def.title, def.sql)
+	view.definitionSQL = def.sql
+	view.definitionReturn = view.State
+	view.State = Definition
+}
+
 func (view *MainView) Render(screen tcell.Screen) {
 	view.editor.Render(screen)
 	view.tableTree.Render(screen)
@@ -248,8 +357,16 @@ func (view *MainView) Render(screen tcell.Screen) {
 		view.schemaList.Render(screen)
 	}
 
-	view.dataTable.Render(screen)
+	if view.showPlan {
+		view.planTree.Render(screen)
+	} else {
+		view.dataTable.Render(screen)
+	}
 	view.status.Render(screen)
+
+	if view.State == Definition {
+		view.definitionView.Render(screen)
+	}
 }
 
 func (view *MainView) EditorInNormalMode() bool {
@@ -278,7 +395,17 @@ func (view *MainView) HandleInput(ev *tcell.EventKey) {
 	case SchemaList:
 		view.schemaList.HandleInput(ev)
 	case TblList:
+		if ev.Rune() == 'S' {
+			view.showDefinition(view.tableTree, view.definitions)
+			break
+		}
 		view.tableTree.HandleInput(ev)
+	case Definition:
+		if ev.Key() == tcell.KeyEsc {
+			view.State = view.definitionReturn
+			break
+		}
+		view.definitionView.HandleInput(ev)
 	case DataTableExpanded:
 		if ev.Key() == tcell.KeyEsc {
 			view.State = DataTable
@@ -290,7 +417,13 @@ func (view *MainView) HandleInput(ev *tcell.EventKey) {
 		}
 		view.dataTable.HandleInput(ev)
 	case Indexes:
+		if ev.Rune() == 'S' {
+			view.showDefinition(view.indexTree, view.indexDefinitions)
+			break
+		}
 		view.indexTree.HandleInput(ev)
+	case Plan:
+		view.planTree.HandleInput(ev)
 	}
 }
 
@@ -311,9 +444,13 @@ func (view *MainView) SetState(state MainViewState) {
 	case TblList:
 		view.status.SetStatus([]rune("Tables"), TableStatusStyle)
 	case DataTable:
+		view.showPlan = false
 		view.status.SetStatus([]rune("DataTable"), DataTableStatusStyle)
 	case Indexes:
 		view.status.SetStatus([]rune("Indexes"), IndexesStatusStyle)
+	case Plan:
+		view.showPlan = true
+		view.status.SetStatus([]rune("Plan"), PlanStatusStyle)
 	}
 }
 
@@ -336,10 +473,162 @@ func (view *MainView) SetInfo(msg []rune) {
 	view.status.SetInfo(msg)
 }
 
+func (view *MainView) SetRunning(start time.Time) {
+	view.status.SetRunning(start)
+}
+
+func (view *MainView) SetProgress(msg []rune) {
+	view.status.SetProgress(msg)
+}
+
+func (view *MainView) StopRunning() {
+	view.status.StopRunning()
+}
+
+func (view *MainView) SetTransaction(status db.TxStatus) {
+	view.status.SetTransaction(status.Open, status.Statements, status.Autocommit)
+}
+
+func (view *MainView) SetPostFunc(post comp.PostFunc) {
+	view.dataTable.SetPostFunc(post)
+}
+
+// Result returns the columns and the rows fetched so far, done is false when
+// the source has rows that haven't been read yet. columns is nil when the
+// table is showing a message instead of rows.
+func (view *MainView) Result() (columns []db.ColumnInfo, rows []db.Row, done bool) {
+	rs, ok := view.dataTable.Source().(*db.ResultSet)
+	if !ok || rs == nil {
+		return nil, nil, true
+	}
+
+	fetched, done := view.dataTable.Rows()
+	for _, v := range fetched {
+		rows = append(rows, v.(db.Row))
+	}
+
+	return rs.Columns(), rows, done
+}
+
+func (view *MainView) StopFetching() {
+	view.dataTable.StopFetching()
+}
+
+func (view *MainView) TableSource() comp.RowSource {
+	return view.dataTable.Source()
+}
+
+func (view *MainView) SelectedCell() (row, col int, ok bool) {
+	return view.dataTable.SelectedCell()
+}
+
+// SetCell replaces a cell in the fetched rows, used once a change to it has
+// been written back.
+func (view *MainView) SetCell(row, col int, value db.Value) {
+	fetched, _ := view.dataTable.Rows()
+	if row < 0 || row >= len(fetched) {
+		return
+	}
+
+	updated := slices.Clone(fetched[row].(db.Row))
+	if col < len(updated) {
+		updated[col] = value
+		view.dataTable.SetRow(row, updated)
+	}
+}
+
+func (view *MainView) MarkedRows() []int {
+	return view.dataTable.MarkedRows()
+}
+
+func (view *MainView) RemoveRows(rows []int) {
+	view.dataTable.RemoveRows(rows)
+}
+
+// SelectedTable is the table selected in the table tree, or the table of
+// the selected column.
+func (view *MainView) SelectedTable() (db.Table, bool) {
+	table, ok := view.tableItems[view.tableTree.SelectedItem()]
+	return table, ok
+}
+
+// TableFunc swaps the plan back out for the data table when new results
+// arrive.
 func (view *MainView) TableFunc() comp.TableDataFunc {
-	return view.dataTable.TableFunc()
+	tableFunc := view.dataTable.TableFunc()
+	return func(source comp.RowSource, resultMsg []rune) {
+		view.showPlan = false
+		if view.State == Plan {
+			view.SetState(DataTable)
+		}
+
+		tableFunc(source, resultMsg)
+	}
 }
 
 func (view *MainView) SetSQLFunc(fn comp.ExecSQLFunc) {
 	view.editor.SetSQLFunc(fn)
 }
+
+func (view *MainView) SetExplainFunc(fn comp.ExecSQLFunc) {
+	view.editor.SetExplainFunc(fn)
+}
+
+func (view *MainView) SetSnippetFunc(fn comp.ExecSQLFunc) {
+	view.editor.SetSnippetFunc(fn)
+}
+
+// CloseDefinition leaves the definition popup and returns the SQL it was
+// showing.
+func (view *MainView) CloseDefinition() string {
+	view.State = view.definitionReturn
+	return view.definitionSQL
+}
+
+// SetDriver splits the editor's statements with the driver's syntax.
+func (view *MainView) SetDriver(driver string) {
+	view.editor.SetStatementFunc(func(script string, offset int) string {
+		return db.StatementAt(driver, script, offset)
+	})
+}
+
+func (view *MainView) InsertText(text []rune) {
+	view.editor.InsertText(text)
+}
+
+// SetPlan shows plan in place of the data table with every node expanded,
+// full table scans are highlighted.
+func (view *MainView) SetPlan(plan *db.PlanNode) {
+	var items []*comp.TreeItem
+	for _, v := range plan.Children {
+		items = append(items, planItem(v, 0))
+	}
+
+	view.planTree.SetItems(items)
+	view.planTree.Reset()
+	view.showPlan = true
+}
+
+func (view *MainView) HasPlan() bool {
+	return view.planTree.SelectedItem() != nil
+}
+
+func planItem(node *db.PlanNode, level int) *comp.TreeItem {
+	item := &comp.TreeItem{
+		Label: []rune(node.Summary()),
+		Value: node.Relation,
+		Level: level,
+		Child: level > 0,
+	}
+
+	if node.FullScan {
+		item.Style = &FullScanStyle
+	}
+
+	for _, v := range node.Children {
+		item.Children = append(item.Children, planItem(v, level+1))
+	}
+
+	item.SetExpanded(len(item.Children) > 0)
+	return item
+}
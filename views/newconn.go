@@ -5,11 +5,7 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	comp "github.com/sleepy-day/sqline/components"
-
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
-	_ "github.com/mattn/go-sqlite3"
-	_ "github.com/microsoft/go-mssqldb"
+	"github.com/sleepy-day/sqline/db"
 )
 
 const (
@@ -20,13 +16,6 @@ const (
 	saveButton
 )
 
-var (
-	driverTypes []comp.ListItem[string] = []comp.ListItem[string]{
-		{Label: []rune("Postgres"), Value: "postgres"},
-		{Label: []rune("Sqlite"), Value: "sqlite3"},
-	}
-)
-
 type TestFunc func(connStr, driver string) error
 type SaveFunc func(name, connStr, driver string)
 type NCVSelected byte
@@ -65,7 +54,7 @@ func CreateNewConnView(left, top, right, bottom int, style, hlStyle *tcell.Style
 	ncView.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune("Add New Connection"), style)
 
 	inpLeft, inpTop, inpRight, inpBottom := ncView.window.RequestRows(4)
-	ncView.driverRadio = comp.CreateRadioSelect(inpLeft, inpTop, inpRight, inpBottom, []rune("Driver:"), driverTypes, style, hlStyle)
+	ncView.driverRadio = comp.CreateRadioSelect(inpLeft, inpTop, inpRight, inpBottom, []rune("Driver:"), driverTypes(), style, hlStyle)
 
 	inpLeft, inpTop, inpRight, _ = ncView.window.RequestRows(4)
 	ncView.nameInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("Name:"), style)
@@ -85,6 +74,18 @@ func CreateNewConnView(left, top, right, bottom int, style, hlStyle *tcell.Style
 	return ncView
 }
 
+func driverTypes() []comp.ListItem[string] {
+	var items []comp.ListItem[string]
+	for _, v := range db.Drivers() {
+		items = append(items, comp.ListItem[string]{
+			Label: []rune(v.Label),
+			Value: v.Name,
+		})
+	}
+
+	return items
+}
+
 func (ncv *NewConnView) ResetFocus() {
 	ncv.driverRadio.LoseFocus()
 	ncv.nameInput.LoseFocus()
@@ -130,6 +131,11 @@ func (ncv *NewConnView) HandleInput(ev *tcell.EventKey) {
 	switch {
 	case ncv.selected == driverRadio:
 		ncv.driverRadio.HandleInput(ev)
+
+		driver, ok := db.GetDriver(ncv.driverRadio.GetSelection())
+		if ok {
+			ncv.infoBox.SetMessage(fmt.Sprintf("Example: %s", driver.ConnStrTemplate))
+		}
 	case ncv.selected == nameInput:
 		ncv.nameInput.HandleInput(ev)
 	case ncv.selected == connStrInput:
@@ -58,6 +58,10 @@ func (ocv *OpenConnView) SetConns(conns []util.DBEntry) {
 	ocv.connList.SetList(items)
 }
 
+func (ocv *OpenConnView) SetTitle(title string) {
+	ocv.connList.SetTitle([]rune(title))
+}
+
 func (ocv *OpenConnView) Render(screen tcell.Screen) {
 	ocv.connList.Render(screen)
 }
@@ -0,0 +1,207 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	comp "github.com/sleepy-day/sqline/components"
+	"github.com/sleepy-day/sqline/util"
+)
+
+const (
+	snippetNameInput SSVSelected = iota
+	snippetScopeRadio
+	snippetSaveButton
+)
+
+const (
+	globalScope     = "Global"
+	connectionScope = "This Connection"
+)
+
+// SaveSnippetFunc saves the selection as a snippet called name, globally or
+// under the current connection.
+type SaveSnippetFunc func(name string, global bool) error
+type SSVSelected byte
+
+// SnippetView lists the snippets for the current connection and the global
+// ones, Enter inserts the selected one into the editor.
+type SnippetView struct {
+	width       int
+	window      *comp.Window
+	snippetList *comp.List[util.ScopedSnippet]
+	infoBox     *comp.InfoBox
+	pasteFunc   PasteFunc
+}
+
+func CreateSnippetView(left, top, right, bottom int, style *tcell.Style, pasteFunc PasteFunc) *SnippetView {
+	sv := &SnippetView{
+		width:     right - left - 4,
+		pasteFunc: pasteFunc,
+	}
+
+	sv.window = comp.CreateWindow(left, top, right, bottom, 1, 1, true, true, []rune("Snippets"), style)
+	sv.snippetList = comp.CreateList[util.ScopedSnippet](left+2, top+2, right-2, bottom-3, nil, nil, style)
+	sv.infoBox = comp.CreateInfoBox(left+2, bottom-3, right-2, bottom-1, style)
+
+	return sv
+}
+
+// SetSnippets replaces the snippets shown.
+func (sv *SnippetView) SetSnippets(snippets []util.ScopedSnippet) {
+	var items []comp.ListItem[util.ScopedSnippet]
+	for _, v := range snippets {
+		items = append(items, comp.ListItem[util.ScopedSnippet]{
+			Label: sv.label(v),
+			Value: v,
+		})
+	}
+
+	sv.snippetList.SetList(items)
+	sv.snippetList.SetTitle([]rune(fmt.Sprintf("%d snippets", len(items))))
+	sv.showSelected()
+}
+
+func (sv *SnippetView) label(snippet util.ScopedSnippet) []rune {
+	scope := "global"
+	if snippet.Connection != "" {
+		scope = snippet.Connection
+	}
+
+	label := []rune(fmt.Sprintf("%-20s [%s]", snippet.Name, scope))
+	if len(label) > sv.width {
+		label = append(label[:sv.width-1], '…')
+	}
+
+	return label
+}
+
+// showSelected previews the selected snippet's SQL on one line.
+func (sv *SnippetView) showSelected() {
+	item := sv.snippetList.SelectedItem()
+	if item == nil {
+		sv.infoBox.SetMessage("No snippets saved, select text in the editor and press S to save one")
+		return
+	}
+
+	sv.infoBox.SetMessage(strings.Join(strings.Fields(item.Value.SQL), " "))
+}
+
+func (sv *SnippetView) Render(screen tcell.Screen) {
+	sv.window.Render(screen)
+	sv.snippetList.Render(screen)
+	sv.infoBox.Render(screen)
+}
+
+func (sv *SnippetView) HandleInput(key *tcell.EventKey) {
+	switch key.Key() {
+	case tcell.KeyUp, tcell.KeyDown:
+		sv.snippetList.HandleInput(key)
+		sv.showSelected()
+	case tcell.KeyEnter:
+		item := sv.snippetList.SelectedItem()
+		if item != nil {
+			sv.pasteFunc(item.Value.SQL)
+		}
+	}
+}
+
+// SaveSnippetView asks for the name and scope of a new snippet.
+type SaveSnippetView struct {
+	selected   SSVSelected
+	window     *comp.Window
+	nameInput  *comp.TextBox
+	scopeRadio *comp.RadioSelect
+	saveBtn    *comp.Button
+	infoBox    *comp.InfoBox
+	saveFunc   SaveSnippetFunc
+}
+
+func CreateSaveSnippetView(left, top, right, bottom int, style, hlStyle *tcell.Style, saveFunc SaveSnippetFunc) *SaveSnippetView {
+	ssv := &SaveSnippetView{
+		selected: snippetNameInput,
+		saveFunc: saveFunc,
+	}
+
+	ssv.window = comp.CreateWindow(left, top, right, bottom, 2, 2, true, true, []rune("Save Snippet"), style)
+
+	inpLeft, inpTop, inpRight, _ := ssv.window.RequestRows(4)
+	ssv.nameInput = comp.CreateTextBox(inpLeft, inpTop, inpRight, []rune("Name:"), style)
+
+	inpLeft, inpTop, inpRight, inpBottom := ssv.window.RequestRows(4)
+	scopes := []comp.ListItem[string]{
+		{Label: []rune(globalScope), Value: globalScope},
+		{Label: []rune(connectionScope), Value: connectionScope},
+	}
+	ssv.scopeRadio = comp.CreateRadioSelect(inpLeft, inpTop, inpRight, inpBottom, []rune("Save To:"), scopes, style, hlStyle)
+
+	inpLeft, inpTop, _, _ = ssv.window.RequestRows(3)
+	ssv.saveBtn = comp.CreateButton(inpLeft, inpTop, []rune("Save"), style)
+
+	inpLeft, inpTop, inpRight, inpBottom = ssv.window.RequestRows(3)
+	ssv.infoBox = comp.CreateInfoBox(inpLeft, inpTop, inpRight, inpBottom, style)
+
+	ssv.nameInput.Focus()
+	return ssv
+}
+
+func (ssv *SaveSnippetView) ResetFocus() {
+	ssv.nameInput.LoseFocus()
+	ssv.scopeRadio.LoseFocus()
+	ssv.saveBtn.LoseFocus()
+}
+
+func (ssv *SaveSnippetView) Render(screen tcell.Screen) {
+	ssv.window.Render(screen)
+	ssv.nameInput.Render(screen)
+	ssv.scopeRadio.Render(screen)
+	ssv.saveBtn.Render(screen)
+	ssv.infoBox.Render(screen)
+}
+
+func (ssv *SaveSnippetView) HandleInput(key *tcell.EventKey) {
+	if key.Key() == tcell.KeyTab {
+		ssv.ResetFocus()
+
+		switch ssv.selected {
+		case snippetNameInput:
+			ssv.selected = snippetScopeRadio
+			ssv.scopeRadio.Focus()
+		case snippetScopeRadio:
+			ssv.selected = snippetSaveButton
+			ssv.saveBtn.Focus()
+		case snippetSaveButton:
+			ssv.selected = snippetNameInput
+			ssv.nameInput.Focus()
+		}
+		return
+	}
+
+	switch {
+	case ssv.selected == snippetNameInput:
+		ssv.nameInput.HandleInput(key)
+	case ssv.selected == snippetScopeRadio:
+		ssv.scopeRadio.HandleInput(key)
+	case ssv.selected == snippetSaveButton && key.Key() == tcell.KeyEnter:
+		scope := ssv.scopeRadio.GetSelection()
+		if scope == "" {
+			ssv.infoBox.SetMessage("No scope selected")
+			break
+		}
+
+		err := ssv.saveFunc(ssv.nameInput.GetString(), scope == globalScope)
+		if err != nil {
+			ssv.infoBox.SetMessage("Error: " + err.Error())
+		}
+	}
+}
+
+func (ssv *SaveSnippetView) Reset() {
+	ssv.ResetFocus()
+	ssv.nameInput.Reset()
+	ssv.scopeRadio.Reset()
+	ssv.infoBox.Reset()
+	ssv.selected = snippetNameInput
+	ssv.nameInput.Focus()
+}